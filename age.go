@@ -0,0 +1,54 @@
+package filemod
+
+import "time"
+
+// Now is the clock used for relative ages, such as Age and ModifiedWithin. By default it
+// is time.Now; set it to (e.g.) a fixed function or MemFS.Now for deterministic tests.
+var Now = time.Now
+
+// Age gets the time elapsed since the file was modified, according to Now.
+// It is zero for files that do not exist.
+func (file FileMetaInfo) Age() time.Duration {
+	if !file.Exists() {
+		return 0
+	}
+	return Now().Sub(file.ModTime())
+}
+
+// ModifiedWithin tests whether the file exists and was modified no longer ago than d.
+func (file FileMetaInfo) ModifiedWithin(d time.Duration) bool {
+	return file.Exists() && file.Age() <= d
+}
+
+// OlderThanDuration tests whether the file exists and was modified longer ago than d.
+func (file FileMetaInfo) OlderThanDuration(d time.Duration) bool {
+	return file.Exists() && file.Age() > d
+}
+
+// ModifiedSince tests whether the file exists and was modified after the time t.
+func (file FileMetaInfo) ModifiedSince(t time.Time) bool {
+	return file.Exists() && file.ModTime().After(t)
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// ModifiedWithin returns only those files that exist and were modified no longer ago than d.
+func (files Files) ModifiedWithin(d time.Duration) Files {
+	return files.Filter(func(f FileMetaInfo) bool {
+		return f.ModifiedWithin(d)
+	})
+}
+
+// OlderThanDuration returns only those files that exist and were modified longer ago than d.
+func (files Files) OlderThanDuration(d time.Duration) Files {
+	return files.Filter(func(f FileMetaInfo) bool {
+		return f.OlderThanDuration(d)
+	})
+}
+
+// ModifiedSince returns only those files that exist and were modified after the time t.
+func (files Files) ModifiedSince(t time.Time) Files {
+	return files.Filter(func(f FileMetaInfo) bool {
+		return f.ModifiedSince(t)
+	})
+}
@@ -0,0 +1,42 @@
+package filemod
+
+import (
+	. "github.com/onsi/gomega"
+	"testing"
+	"time"
+)
+
+func TestAge(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	t0 := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	mem := NewMemFS(t0)
+	g.Expect(mem.WriteFile("a", nil, 0644)).To(Succeed())
+	mem.Advance(time.Hour)
+	g.Expect(mem.WriteFile("b", nil, 0644)).To(Succeed())
+	mem.Advance(time.Hour)
+	Now = mem.Now
+	defer func() { Now = time.Now }()
+	files := NewWith(mem, "a", "b", "c")
+
+	// Then...
+	g.Expect(files[0].Age()).To(Equal(2 * time.Hour))
+	g.Expect(files[1].Age()).To(Equal(time.Hour))
+	g.Expect(files[2].Age()).To(BeZero())
+
+	g.Expect(files[0].ModifiedWithin(2 * time.Hour)).To(BeTrue())
+	g.Expect(files[0].ModifiedWithin(time.Hour)).To(BeFalse())
+	g.Expect(files[2].ModifiedWithin(time.Hour)).To(BeFalse())
+
+	g.Expect(files[0].OlderThanDuration(time.Hour)).To(BeTrue())
+	g.Expect(files[1].OlderThanDuration(time.Hour)).To(BeFalse())
+	g.Expect(files[2].OlderThanDuration(time.Hour)).To(BeFalse())
+
+	g.Expect(files[1].ModifiedSince(t0)).To(BeTrue())
+	g.Expect(files[0].ModifiedSince(t0)).To(BeFalse())
+
+	g.Expect(paths(files.ModifiedWithin(90 * time.Minute))).To(Equal([]string{"b"}))
+	g.Expect(paths(files.OlderThanDuration(90 * time.Minute))).To(Equal([]string{"a"}))
+	g.Expect(paths(files.ModifiedSince(t0.Add(-time.Second)))).To(Equal([]string{"a", "b"}))
+	g.Expect(paths(DeleteOlderThan(time.Hour)(files))).To(Equal([]string{"a"}))
+}
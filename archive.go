@@ -0,0 +1,349 @@
+package filemod
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Archive is a read-only Statter that exposes the members of a tar or zip archive as
+// files. It is also a DirReader and an Opener, so archives can be walked and their
+// content can be hashed.
+//
+// Member paths are relative to the root of the archive, which is ".". Directories that
+// are implied by the member paths but absent from the archive are provided automatically.
+//
+// Tar archives can only be read sequentially, so the first time a member of a tar archive
+// is opened, the content of every member is read and kept in memory.
+type Archive struct {
+	name    string
+	members map[string]*member
+	zip     *zip.ReadCloser
+
+	mu     sync.Mutex
+	loaded bool // whether the content of tar members has been read
+}
+
+type member struct {
+	info     os.FileInfo
+	link     string // target of a symbolic or hard link
+	hard     bool
+	children map[string]*member
+	zf       *zip.File
+	data     []byte // content of a tar member, once loaded
+}
+
+// OpenArchive opens a tar, gzipped tar or zip archive, choosing the format from the file
+// name extension (.tar, .tar.gz, .tgz, .zip). The archive should be closed after use.
+func OpenArchive(name string) (*Archive, error) {
+	if strings.EqualFold(filepath.Ext(name), ".zip") {
+		return OpenZip(name)
+	}
+	return OpenTar(name)
+}
+
+// OpenTar opens a tar archive, which may be gzipped. The archive should be closed after use.
+func OpenTar(name string) (*Archive, error) {
+	a := newArchive(name)
+
+	err := a.scanTar(func(hdr *tar.Header, _ io.Reader) bool {
+		m := &member{info: hdr.FileInfo()}
+		switch hdr.Typeflag {
+		case tar.TypeSymlink:
+			m.link = hdr.Linkname
+		case tar.TypeLink:
+			m.link, m.hard = cleanMember(hdr.Linkname), true
+		}
+		a.add(hdr.Name, m)
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// OpenZip opens a zip archive. The archive should be closed after use.
+func OpenZip(name string) (*Archive, error) {
+	zr, err := zip.OpenReader(name)
+	if err != nil {
+		return nil, err
+	}
+
+	a := newArchive(name)
+	a.zip = zr
+
+	for _, zf := range zr.File {
+		m := &member{info: zf.FileInfo(), zf: zf}
+		if zf.Mode()&os.ModeSymlink != 0 {
+			if m.link, err = readLink(zf); err != nil {
+				zr.Close()
+				return nil, err
+			}
+		}
+		a.add(zf.Name, m)
+	}
+
+	return a, nil
+}
+
+func readLink(zf *zip.File) (string, error) {
+	rc, err := zf.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	b := &strings.Builder{}
+	_, err = io.Copy(b, rc)
+	return b.String(), err
+}
+
+func newArchive(name string) *Archive {
+	root := &member{info: archiveDir{name: "."}, children: make(map[string]*member)}
+	return &Archive{name: name, members: map[string]*member{".": root}}
+}
+
+// cleanMember converts a path to the canonical form of a member path.
+func cleanMember(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(name)), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+func (a *Archive) add(name string, m *member) {
+	name = cleanMember(name)
+	if name == "." {
+		return
+	}
+
+	if existing, exists := a.members[name]; exists && existing.children != nil {
+		m.children = existing.children // an implied directory is now explicit
+	}
+	if m.info.IsDir() && m.children == nil {
+		m.children = make(map[string]*member)
+	}
+
+	a.members[name] = m
+	a.dir(path.Dir(name), m.info.ModTime()).children[path.Base(name)] = m
+}
+
+// dir gets the member for a directory, providing it if it is absent. The modification
+// time of a provided directory is that of the newest member within it.
+func (a *Archive) dir(name string, modTime time.Time) *member {
+	if d, exists := a.members[name]; exists {
+		if implied, ok := d.info.(archiveDir); ok && modTime.After(implied.modTime) {
+			implied.modTime = modTime
+			d.info = implied
+			if name != "." {
+				a.dir(path.Dir(name), modTime)
+			}
+		}
+		if d.children == nil {
+			d.children = make(map[string]*member) // malformed: a file is used as a directory
+		}
+		return d
+	}
+	d := &member{info: archiveDir{name: path.Base(name), modTime: modTime}, children: make(map[string]*member)}
+	a.members[name] = d
+	a.dir(path.Dir(name), modTime).children[path.Base(name)] = d
+	return d
+}
+
+// scanTar reads through the tar archive, calling fn for each member until it returns false.
+func (a *Archive) scanTar(fn func(*tar.Header, io.Reader) bool) error {
+	f, err := os.Open(a.name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = bufio.NewReader(f)
+	if magic, _ := r.(*bufio.Reader).Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if !fn(hdr, tr) {
+			return nil
+		}
+	}
+}
+
+// Close releases the resources held by the archive.
+func (a *Archive) Close() error {
+	if a.zip != nil {
+		return a.zip.Close()
+	}
+	return nil
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// maxLinks limits how many symbolic links are followed, to avoid loops.
+const maxLinks = 40
+
+func (a *Archive) lookup(op, name string, follow bool) (string, *member, error) {
+	name = cleanMember(name)
+	for i := 0; i < maxLinks; i++ {
+		m, exists := a.members[name]
+		if !exists {
+			return name, nil, &os.PathError{Op: op, Path: name, Err: os.ErrNotExist}
+		}
+		if m.link == "" || !(follow || m.hard) {
+			return name, m, nil
+		}
+		if m.hard || path.IsAbs(m.link) {
+			name = cleanMember(m.link)
+		} else {
+			name = cleanMember(path.Join(path.Dir(name), m.link))
+		}
+	}
+	return name, nil, &os.PathError{Op: op, Path: name, Err: errTooManyLinks}
+}
+
+// Stat gets information about a member, following symbolic links.
+func (a *Archive) Stat(name string) (os.FileInfo, error) {
+	_, m, err := a.lookup("stat", name, true)
+	if err != nil {
+		return nil, err
+	}
+	return m.info, nil
+}
+
+// Lstat gets information about a member without following symbolic links.
+func (a *Archive) Lstat(name string) (os.FileInfo, error) {
+	_, m, err := a.lookup("lstat", name, false)
+	if err != nil {
+		return nil, err
+	}
+	return m.info, nil
+}
+
+// ReadDir lists the members within a directory, in name order.
+func (a *Archive) ReadDir(name string) ([]os.FileInfo, error) {
+	_, m, err := a.lookup("readdir", name, true)
+	if err != nil {
+		return nil, err
+	}
+	if m.children == nil {
+		return nil, &os.PathError{Op: "readdir", Path: name, Err: errNotDir}
+	}
+
+	result := make([]os.FileInfo, 0, len(m.children))
+	for _, c := range m.children {
+		result = append(result, c.info)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name() < result[j].Name()
+	})
+	return result, nil
+}
+
+// Open opens a member for reading, following symbolic links.
+func (a *Archive) Open(name string) (io.ReadCloser, error) {
+	_, m, err := a.lookup("open", name, true)
+	if err != nil {
+		return nil, err
+	}
+	if m.children != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: errIsDir}
+	}
+
+	if m.zf != nil {
+		return m.zf.Open()
+	}
+
+	if err = a.loadTar(); err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(m.data)), nil
+}
+
+// loadTar reads the content of all the members of a tar archive in one pass, so that
+// opening each member does not mean reading the archive again.
+func (a *Archive) loadTar() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.loaded {
+		return nil
+	}
+
+	Debug("read %q\n", a.name)
+	var readErr error
+	err := a.scanTar(func(hdr *tar.Header, r io.Reader) bool {
+		m, exists := a.members[cleanMember(hdr.Name)]
+		if !exists || m.link != "" || m.children != nil {
+			return true
+		}
+		m.data, readErr = ioutil.ReadAll(r)
+		return readErr == nil
+	})
+	if err == nil {
+		err = readErr
+	}
+	if err != nil {
+		return err
+	}
+
+	a.loaded = true
+	return nil
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// archiveDir is the information for a directory implied by the paths of archive members.
+type archiveDir struct {
+	name    string
+	modTime time.Time
+}
+
+func (d archiveDir) Name() string {
+	return d.name
+}
+
+func (d archiveDir) Size() int64 {
+	return 0
+}
+
+func (d archiveDir) Mode() os.FileMode {
+	return os.ModeDir | 0755
+}
+
+func (d archiveDir) ModTime() time.Time {
+	return d.modTime
+}
+
+func (d archiveDir) IsDir() bool {
+	return true
+}
+
+func (d archiveDir) Sys() interface{} {
+	return nil
+}
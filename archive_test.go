@@ -0,0 +1,159 @@
+package filemod
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	. "github.com/onsi/gomega"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var archiveTime = time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// archiveTree creates a tree of files on disk matching the archives built below.
+func archiveTree(g *WithT) string {
+	dir := tempTree(g, "a", "d/b", "d/c")
+	for _, name := range []string{"a", "d/b", "d/c", "d"} {
+		g.Expect(os.Chtimes(filepath.Join(dir, name), archiveTime, archiveTime)).To(Succeed())
+	}
+	return dir
+}
+
+func writeTar(g *WithT, name string, gzipped bool) {
+	f, err := os.Create(name)
+	g.Expect(err).NotTo(HaveOccurred())
+	defer f.Close()
+
+	var w io.Writer = f
+	if gzipped {
+		gz := gzip.NewWriter(f)
+		defer gz.Close()
+		w = gz
+	}
+
+	tw := tar.NewWriter(w)
+	defer tw.Close()
+
+	for _, name := range []string{"a", "d/b", "d/c"} {
+		hdr := &tar.Header{Name: "./" + name, Mode: 0644, Size: int64(len(name)), ModTime: archiveTime, Typeflag: tar.TypeReg}
+		g.Expect(tw.WriteHeader(hdr)).To(Succeed())
+		_, err = tw.Write([]byte(name))
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+
+	hdr := &tar.Header{Name: "l", Linkname: "d/b", Mode: 0777, ModTime: archiveTime, Typeflag: tar.TypeSymlink}
+	g.Expect(tw.WriteHeader(hdr)).To(Succeed())
+}
+
+func writeZip(g *WithT, name string) {
+	f, err := os.Create(name)
+	g.Expect(err).NotTo(HaveOccurred())
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	defer zw.Close()
+
+	for _, name := range []string{"d/", "a", "d/b", "d/c-changed"} {
+		hdr := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: archiveTime}
+		hdr.SetMode(0644)
+		if name == "d/" {
+			hdr.SetMode(os.ModeDir | 0755)
+		}
+		w, err := zw.CreateHeader(hdr)
+		g.Expect(err).NotTo(HaveOccurred())
+		if name != "d/" {
+			_, err = w.Write([]byte(name))
+			g.Expect(err).NotTo(HaveOccurred())
+		}
+	}
+}
+
+func TestTarArchive(t *testing.T) {
+	for _, name := range []string{"x.tar", "x.tar.gz"} {
+		g := NewGomegaWithT(t)
+		// Given...
+		fs = osFacade{} // the real deal
+		dir := archiveTree(g)
+		defer os.RemoveAll(dir)
+		arcName := filepath.Join(dir, "..", filepath.Base(dir)+name)
+		writeTar(g, arcName, name == "x.tar.gz")
+		defer os.Remove(arcName)
+
+		// When...
+		arc, err := OpenArchive(arcName)
+		g.Expect(err).NotTo(HaveOccurred())
+		defer arc.Close()
+
+		members, err := WalkWith(arc, ".")
+		g.Expect(err).NotTo(HaveOccurred())
+		disk, err := Walk(dir)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		// Then...
+		g.Expect(paths(members)).To(Equal([]string{".", "a", "d", "d/b", "d/c", "l"}))
+		g.Expect(members[2].IsDir()).To(BeTrue())
+		g.Expect(members[2].ModTime()).To(BeTemporally("==", archiveTime)) // implied by its contents
+		g.Expect(members[3].Size()).To(BeEquivalentTo(3))
+		g.Expect(members[3].ModTime()).To(BeTemporally("==", archiveTime))
+		g.Expect(members[5].Mode() & os.ModeSymlink).NotTo(BeZero())
+
+		l := StatWith(arc, "l")
+		g.Expect(l.Name()).To(Equal("b"))
+		g.Expect(ContentHash(l)).To(Equal(ContentHash(Stat(filepath.Join(dir, "d", "b")))))
+
+		g.Expect(os.Remove(arcName)).To(Succeed()) // the content has been read already
+		rc, err := arc.Open("d/c")
+		g.Expect(err).NotTo(HaveOccurred())
+		content, _ := ioutil.ReadAll(rc)
+		rc.Close()
+		g.Expect(string(content)).To(Equal("d/c"))
+
+		changes := Diff(members[1:5], disk[1:], RelativeTo(".", dir))
+		g.Expect(changes).To(BeEmpty())
+		g.Expect(members.AllAreNewerThan(disk)).To(BeFalse())
+
+		g.Expect(StatWith(arc, "nope").Exists()).To(BeFalse())
+	}
+}
+
+func TestZipArchive(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	dir := archiveTree(g)
+	defer os.RemoveAll(dir)
+	arcName := dir + ".zip"
+	writeZip(g, arcName)
+	defer os.Remove(arcName)
+
+	// When...
+	arc, err := OpenArchive(arcName)
+	g.Expect(err).NotTo(HaveOccurred())
+	defer arc.Close()
+
+	members, err := WalkWith(arc, ".")
+	g.Expect(err).NotTo(HaveOccurred())
+	disk, err := Walk(dir)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// Then...
+	g.Expect(paths(members)).To(Equal([]string{".", "a", "d", "d/b", "d/c-changed"}))
+
+	changes := Diff(disk[1:], members[1:], RelativeTo(".", dir))
+	g.Expect(changes).To(HaveLen(2))
+	g.Expect(changes[0].Kind).To(Equal(Removed))
+	g.Expect(changes[0].Key).To(Equal("d/c"))
+	g.Expect(changes[1].Kind).To(Equal(Added))
+	g.Expect(changes[1].Key).To(Equal("d/c-changed"))
+
+	rc, err := members[3].Open()
+	g.Expect(err).NotTo(HaveOccurred())
+	content, _ := ioutil.ReadAll(rc)
+	rc.Close()
+	g.Expect(string(content)).To(Equal("d/b"))
+}
@@ -0,0 +1,156 @@
+package filemod
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// AtomicWriter writes a file atomically, but only if its content changes. This avoids
+// needlessly updating the modification times of outputs, such as generated code, that
+// would otherwise trigger downstream rebuilds.
+//
+// The content is written to a temporary file in the same directory. On Close, it is
+// compared with the existing file. If they differ, the temporary file replaces the
+// existing one by renaming, which is atomic; otherwise it is removed and the existing
+// file is left alone.
+//
+// If the file is a symbolic link, the link itself is replaced by a regular file; the
+// file it points to is not written.
+type AtomicWriter struct {
+	name    string
+	perm    os.FileMode
+	tmp     *os.File
+	changed bool
+}
+
+// NewAtomicWriter starts writing a file atomically. The file will have the given
+// permissions if it is replaced (the umask is not applied).
+func NewAtomicWriter(name string, perm os.FileMode) (*AtomicWriter, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".tmp*")
+	if err != nil {
+		return nil, err
+	}
+	return &AtomicWriter{name: name, perm: perm, tmp: tmp}, nil
+}
+
+// Write writes to the temporary file.
+func (w *AtomicWriter) Write(p []byte) (int, error) {
+	return w.tmp.Write(p)
+}
+
+// Close completes the writing, replacing the file if its content has changed. The new
+// content is synced to disk before the file is replaced, and the directory afterwards,
+// so that a crash cannot leave the file empty, and once Close has returned the new
+// content survives a crash.
+func (w *AtomicWriter) Close() error {
+	tmpName := w.tmp.Name()
+	same, err := sameContent(tmpName, w.name)
+	if err == nil && !same {
+		err = w.tmp.Sync()
+	}
+	if cerr := w.tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil || same {
+		os.Remove(tmpName)
+		return err
+	}
+
+	if err = os.Chmod(tmpName, w.perm); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	Debug("replace %q\n", w.name)
+	if err = os.Rename(tmpName, w.name); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	w.changed = true
+	return syncDir(filepath.Dir(w.name))
+}
+
+// Abort abandons the writing, leaving the file alone.
+func (w *AtomicWriter) Abort() error {
+	w.tmp.Close()
+	return os.Remove(w.tmp.Name())
+}
+
+// Changed reports whether Close replaced the file.
+func (w *AtomicWriter) Changed() bool {
+	return w.changed
+}
+
+// WriteIfChanged writes data to a file atomically, unless the file already holds that
+// data, in which case it is left alone. It reports whether the file was changed.
+func WriteIfChanged(name string, data []byte, perm os.FileMode) (changed bool, err error) {
+	w, err := NewAtomicWriter(name, perm)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err = w.Write(data); err != nil {
+		w.Abort()
+		return false, err
+	}
+
+	err = w.Close()
+	return w.Changed(), err
+}
+
+// sameContent compares a new file with an existing one, which may not exist.
+func sameContent(newName, oldName string) (bool, error) {
+	newInfo, err := os.Stat(newName)
+	if err != nil {
+		return false, err
+	}
+
+	oldInfo, err := os.Stat(oldName)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if !oldInfo.Mode().IsRegular() || oldInfo.Size() != newInfo.Size() {
+		return false, nil
+	}
+
+	a, err := os.Open(newName)
+	if err != nil {
+		return false, err
+	}
+	defer a.Close()
+
+	b, err := os.Open(oldName)
+	if err != nil {
+		return false, err
+	}
+	defer b.Close()
+
+	bufA := make([]byte, 32*1024)
+	bufB := make([]byte, 32*1024)
+	for {
+		nA, errA := io.ReadFull(a, bufA)
+		nB, errB := io.ReadFull(b, bufB)
+		if !bytes.Equal(bufA[:nA], bufB[:nB]) {
+			return false, nil
+		}
+
+		endA := errA == io.EOF || errA == io.ErrUnexpectedEOF
+		endB := errB == io.EOF || errB == io.ErrUnexpectedEOF
+		switch {
+		case endA && endB:
+			return true, nil
+		case errA != nil && !endA:
+			return false, errA
+		case errB != nil && !endB:
+			return false, errB
+		case endA != endB:
+			return false, nil
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package filemod
+
+import (
+	"bytes"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWriteIfChanged(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	dir := tempTree(g)
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "gen.go")
+	older := time.Now().Add(-time.Hour).Truncate(time.Second)
+	big := bytes.Repeat([]byte("0123456789"), 10000)
+
+	// When...
+	changed1, err1 := WriteIfChanged(name, big, 0644)
+	g.Expect(os.Chtimes(name, older, older)).To(Succeed())
+	changed2, err2 := WriteIfChanged(name, big, 0644)
+	mtime2 := Stat(name).ModTime()
+	changed3, err3 := WriteIfChanged(name, append(big[:len(big)-1], 'x'), 0600)
+
+	// Then...
+	g.Expect(err1).NotTo(HaveOccurred())
+	g.Expect(err2).NotTo(HaveOccurred())
+	g.Expect(err3).NotTo(HaveOccurred())
+	g.Expect([]bool{changed1, changed2, changed3}).To(Equal([]bool{true, false, true}))
+	g.Expect(mtime2).To(BeTemporally("==", older))
+	g.Expect(Stat(name).ModTime()).To(BeTemporally(">", older))
+	g.Expect(Stat(name).Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+	list, err := ioutil.ReadDir(dir)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(list).To(HaveLen(1)) // no temporary files are left behind
+}
+
+func TestWriteIfChangedReplacesSymlink(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	dir := tempTree(g, "target")
+	defer os.RemoveAll(dir)
+	target, link := filepath.Join(dir, "target"), filepath.Join(dir, "link")
+	g.Expect(os.Symlink(target, link)).To(Succeed())
+
+	// When...
+	changed, err := WriteIfChanged(link, []byte("new"), 0644)
+
+	// Then...
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(changed).To(BeTrue())
+	g.Expect(Stat(link).Mode().IsRegular()).To(BeTrue())
+	g.Expect(ioutil.ReadFile(target)).To(Equal([]byte("target")))
+}
+
+func TestAtomicWriterAbort(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	dir := tempTree(g, "a")
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "a")
+
+	// When...
+	w, err := NewAtomicWriter(name, 0644)
+	g.Expect(err).NotTo(HaveOccurred())
+	_, err = w.Write([]byte("half-written"))
+	g.Expect(err).NotTo(HaveOccurred())
+	err = w.Abort()
+
+	// Then...
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(w.Changed()).To(BeFalse())
+	content, _ := ioutil.ReadFile(name)
+	g.Expect(string(content)).To(Equal("a"))
+	list, _ := ioutil.ReadDir(dir)
+	g.Expect(list).To(HaveLen(1))
+}
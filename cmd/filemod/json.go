@@ -0,0 +1,33 @@
+package main
+
+import "github.com/rickb777/filemod"
+
+type comparisonJSON struct {
+	Result      bool          `json:"result"`
+	Missing     filemod.Files `json:"missing,omitempty"`
+	Violations  filemod.Files `json:"violations,omitempty"`
+	Explanation string        `json:"explanation,omitempty"`
+}
+
+func newComparisonJSON(result bool, missing filemod.Files, report filemod.ComparisonReport, explain bool) comparisonJSON {
+	cj := comparisonJSON{
+		Result:     result,
+		Missing:    missing,
+		Violations: report.NotNewer(),
+	}
+	if explain {
+		cj.Explanation = report.String()
+	}
+	return cj
+}
+
+type partitionJSON struct {
+	Files  filemod.Files `json:"files"`
+	Dirs   filemod.Files `json:"directories"`
+	Absent filemod.Files `json:"absent"`
+}
+
+type changeJSON struct {
+	Kind string `json:"kind"`
+	Path string `json:"path"`
+}
@@ -0,0 +1,386 @@
+// Command filemod compares and lists files by their modification times, for use
+// in shell scripts and Makefiles.
+//
+// The exit status is 0 for success (or true), 1 for false and 2 for errors. When
+// the command given to 'filemod run' fails, its own exit status is passed through
+// instead, so 1 and 2 can also come from that command.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/rickb777/filemod"
+)
+
+const (
+	exitTrue  = 0
+	exitFalse = 1
+	exitError = 2
+)
+
+const usage = `Usage: filemod <command> [options] [arguments]
+
+Commands:
+  newer     A... -- B...      succeeds if all of A are newer than all of B
+  stale     OUT... -- IN...   succeeds if any OUT is missing or is not newer than all IN
+  ls        PATH...           lists files
+  partition PATH...           lists files, directories and absent items separately
+  diff      TREE1 TREE2       lists the differences between two trees; succeeds if there are none.
+                              Each tree is a directory or a tar, tar.gz or zip archive.
+  run       -o OUT... -i IN... -- COMMAND [ARG...]
+                              runs the command only if any OUT is missing or is not newer than all IN
+
+Use 'filemod <command> -h' for the options of each command.
+The exit status is 0 for success (or true), 1 for false and 2 for errors.
+When the command given to run fails, its own exit status is passed through.
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitError
+	}
+
+	cmd := &command{name: args[0], stdout: stdout, stderr: stderr}
+	cmd.flags = flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	cmd.flags.SetOutput(stderr)
+	cmd.flags.BoolVar(&cmd.json, "json", false, "write the output as JSON")
+
+	switch cmd.name {
+	case "newer":
+		return cmd.newer(args[1:])
+	case "stale":
+		return cmd.stale(args[1:])
+	case "ls":
+		return cmd.ls(args[1:])
+	case "partition":
+		return cmd.partition(args[1:])
+	case "diff":
+		return cmd.diff(args[1:])
+	case "run":
+		return cmd.run(args[1:])
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(stdout, usage)
+		return exitTrue
+	}
+
+	fmt.Fprintf(stderr, "filemod: unknown command %q\n\n%s", cmd.name, usage)
+	return exitError
+}
+
+type command struct {
+	name           string
+	flags          *flag.FlagSet
+	json, csv      bool
+	format         filemod.Format
+	stdout, stderr io.Writer
+}
+
+func (cmd *command) parse(args []string) bool {
+	return cmd.flags.Parse(args) == nil
+}
+
+func (cmd *command) fail(format string, args ...interface{}) int {
+	fmt.Fprintf(cmd.stderr, "filemod %s: "+format+"\n", append([]interface{}{cmd.name}, args...)...)
+	return exitError
+}
+
+// splitArgs separates the arguments before and after a "--" separator.
+func (cmd *command) splitArgs() (before, after []string, ok bool) {
+	args := cmd.flags.Args()
+	for i, a := range args {
+		if a == "--" {
+			return args[:i], args[i+1:], i > 0 && i < len(args)-1
+		}
+	}
+	return nil, nil, false
+}
+
+func (cmd *command) writeJSON(v interface{}) {
+	enc := json.NewEncoder(cmd.stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func outcome(ok bool) int {
+	if ok {
+		return exitTrue
+	}
+	return exitFalse
+}
+
+//-------------------------------------------------------------------------------------------------
+
+func (cmd *command) newer(args []string) int {
+	explain := cmd.flags.Bool("explain", false, "explain the outcome")
+	if !cmd.parse(args) {
+		return exitError
+	}
+
+	a, b, ok := cmd.splitArgs()
+	if !ok {
+		return cmd.fail("expected A... -- B...")
+	}
+
+	report := filemod.New(a...).Compare(filemod.New(b...))
+	result := report.AllAreNewer()
+
+	if cmd.json {
+		cmd.writeJSON(newComparisonJSON(result, nil, report, *explain))
+	} else if *explain {
+		fmt.Fprint(cmd.stdout, report)
+	}
+
+	return outcome(result)
+}
+
+func (cmd *command) stale(args []string) int {
+	explain := cmd.flags.Bool("explain", false, "explain the outcome")
+	if !cmd.parse(args) {
+		return exitError
+	}
+
+	out, in, ok := cmd.splitArgs()
+	if !ok {
+		return cmd.fail("expected OUT... -- IN...")
+	}
+
+	inputs := filemod.New(in...)
+	if missing := inputs.AbsentOnly(); len(missing) > 0 {
+		return cmd.fail("missing input %s", missing[0].Path())
+	}
+
+	outputs := filemod.New(out...)
+	missing := outputs.AbsentOnly()
+	report := outputs.Compare(inputs)
+	result := len(missing) > 0 || !report.AllAreNewer()
+
+	if cmd.json {
+		cmd.writeJSON(newComparisonJSON(result, missing, report, *explain))
+	} else if *explain {
+		for _, m := range missing {
+			fmt.Fprintf(cmd.stdout, "missing: %s\n", m.Path())
+		}
+		fmt.Fprint(cmd.stdout, report)
+	}
+
+	return outcome(result)
+}
+
+func (cmd *command) ls(args []string) int {
+	order := cmd.flags.String("sort", "", "sort by 'mtime', 'path' or 'size'")
+	columns := cmd.flags.String("columns", "", "comma-separated columns: path, name, size, mode, modtime, error")
+	cmd.flags.BoolVar(&cmd.csv, "csv", false, "write the output as CSV")
+	cmd.flags.BoolVar(&cmd.format.HumanSizes, "human", false, "show sizes in units such as K and M")
+	cmd.flags.BoolVar(&cmd.format.RelativeTimes, "age", false, "show modification times as ages")
+	if !cmd.parse(args) {
+		return exitError
+	}
+
+	if *columns != "" {
+		var err error
+		if cmd.format.Columns, err = filemod.ParseColumns(*columns); err != nil {
+			return cmd.fail("%v", err)
+		}
+	}
+
+	files := filemod.New(cmd.flags.Args()...)
+
+	switch *order {
+	case "":
+	case "mtime":
+		files.SortedByModTime()
+	case "path":
+		files.SortedByPath()
+	case "size":
+		files.SortedBySize()
+	default:
+		return cmd.fail("unknown sort order %q", *order)
+	}
+
+	cmd.list(files)
+	return cmd.errors(files)
+}
+
+func (cmd *command) partition(args []string) int {
+	if !cmd.parse(args) {
+		return exitError
+	}
+
+	files := filemod.New(cmd.flags.Args()...)
+	allFiles, allDirs, absent := files.Partition()
+
+	if cmd.json {
+		cmd.writeJSON(partitionJSON{Files: allFiles, Dirs: allDirs, Absent: absent})
+	} else {
+		cmd.section("files", allFiles)
+		cmd.section("directories", allDirs)
+		cmd.section("absent", absent)
+	}
+
+	return cmd.errors(files)
+}
+
+func (cmd *command) diff(args []string) int {
+	if !cmd.parse(args) {
+		return exitError
+	}
+
+	if cmd.flags.NArg() != 2 {
+		return cmd.fail("expected TREE1 TREE2")
+	}
+
+	before, root1, err := walkTree(cmd.flags.Arg(0))
+	if err != nil {
+		return cmd.fail("%v", err)
+	}
+
+	after, root2, err := walkTree(cmd.flags.Arg(1))
+	if err != nil {
+		return cmd.fail("%v", err)
+	}
+
+	// the roots themselves are not compared
+	changes := filemod.DiffRelative(before[1:], after[1:], root1, root2)
+
+	if cmd.json {
+		result := make([]changeJSON, len(changes))
+		for i, c := range changes {
+			result[i] = changeJSON{Kind: c.Kind.String(), Path: c.Key}
+		}
+		cmd.writeJSON(result)
+	} else {
+		for _, c := range changes {
+			fmt.Fprintf(cmd.stdout, "%c %s\n", changeSymbol[c.Kind], c.Key)
+		}
+	}
+
+	return outcome(len(changes) == 0)
+}
+
+// run parses its own arguments because each of -o and -i is followed by a list of files.
+func (cmd *command) run(args []string) int {
+	var outputs, inputs []string
+	var list *[]string
+
+	for i, a := range args {
+		switch {
+		case a == "-o":
+			list = &outputs
+		case a == "-i":
+			list = &inputs
+		case a == "--" && len(outputs) > 0 && i < len(args)-1:
+			return cmd.runIfStale(outputs, inputs, args[i+1:])
+		case a != "--" && list != nil:
+			*list = append(*list, a)
+		default:
+			return cmd.fail("expected -o OUT... -i IN... -- COMMAND [ARG...]")
+		}
+	}
+
+	return cmd.fail("expected -o OUT... -i IN... -- COMMAND [ARG...]")
+}
+
+func (cmd *command) runIfStale(outputs, inputs, command []string) int {
+	code := exitTrue
+	ran, err := filemod.RunIfStale(outputs, inputs, func() error {
+		c := exec.Command(command[0], command[1:]...)
+		c.Stdin = os.Stdin
+		c.Stdout = cmd.stdout
+		c.Stderr = cmd.stderr
+		err := c.Run()
+		if exit, ok := err.(*exec.ExitError); ok {
+			code = exit.ExitCode()
+			if code < 0 {
+				code = exitError // killed by a signal
+			}
+		}
+		return err
+	})
+
+	if err != nil {
+		if ran && code != exitTrue {
+			fmt.Fprintf(cmd.stderr, "filemod run: %s: %v\n", command[0], err)
+			return code
+		}
+		return cmd.fail("%v", err)
+	}
+
+	return exitTrue
+}
+
+// walkTree walks a directory, or the members of a tar or zip archive. Directories are
+// walked using absolute paths so that their keys cannot be confused with archive members.
+// A tree that does not exist is an error rather than being treated as empty.
+func walkTree(name string) (filemod.Files, string, error) {
+	if f := filemod.Stat(name); f.Exists() && !f.IsDir() {
+		arc, err := filemod.OpenArchive(name)
+		if err != nil {
+			return nil, "", err
+		}
+		defer arc.Close()
+		files, err := filemod.WalkWith(arc, ".")
+		return files, ".", err
+	}
+
+	root, err := filepath.Abs(name)
+	if err != nil {
+		return nil, "", err
+	}
+	files, err := filemod.Walk(root)
+	if err := files[0].Err(); err != nil {
+		return nil, "", err
+	}
+	if !files[0].Exists() {
+		return nil, "", &os.PathError{Op: "diff", Path: name, Err: os.ErrNotExist}
+	}
+	return files, root, err
+}
+
+var changeSymbol = map[filemod.ChangeKind]rune{
+	filemod.Added:    '+',
+	filemod.Removed:  '-',
+	filemod.Modified: 'M',
+}
+
+//-------------------------------------------------------------------------------------------------
+
+func (cmd *command) section(label string, files filemod.Files) {
+	if len(files) > 0 {
+		fmt.Fprintf(cmd.stdout, "%s:\n", label)
+		cmd.list(files)
+	}
+}
+
+func (cmd *command) list(files filemod.Files) {
+	switch {
+	case cmd.json:
+		files.WriteJSON(cmd.stdout)
+	case cmd.csv:
+		files.WriteCSV(cmd.stdout, cmd.format)
+	default:
+		files.WriteTable(cmd.stdout, cmd.format)
+	}
+}
+
+// errors reports any errors encountered when checking the files.
+func (cmd *command) errors(files filemod.Files) int {
+	ee := files.Errors()
+	for _, e := range ee {
+		fmt.Fprintf(cmd.stderr, "filemod %s: %v\n", cmd.name, e)
+	}
+	if len(ee) > 0 {
+		return exitError
+	}
+	return exitTrue
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func setup(g *WithT) (dir, older, newer string) {
+	dir, err := ioutil.TempDir("", "filemod")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	older = filepath.Join(dir, "old")
+	newer = filepath.Join(dir, "new")
+	g.Expect(ioutil.WriteFile(older, []byte("old"), 0644)).To(Succeed())
+	g.Expect(ioutil.WriteFile(newer, []byte("new"), 0644)).To(Succeed())
+
+	then := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	g.Expect(os.Chtimes(older, then, then)).To(Succeed())
+	return dir, older, newer
+}
+
+func runCmd(args ...string) (int, string, string) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	code := run(args, stdout, stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestNewer(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir, older, newer := setup(g)
+	defer os.RemoveAll(dir)
+
+	code, _, _ := runCmd("newer", newer, "--", older)
+	g.Expect(code).To(Equal(exitTrue))
+
+	code, stdout, _ := runCmd("newer", "-explain", older, "--", newer)
+	g.Expect(code).To(Equal(exitFalse))
+	g.Expect(stdout).To(HavePrefix("relation: all are older\n"))
+
+	code, _, stderr := runCmd("newer", older, newer)
+	g.Expect(code).To(Equal(exitError))
+	g.Expect(stderr).To(Equal("filemod newer: expected A... -- B...\n"))
+}
+
+func TestStale(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir, older, newer := setup(g)
+	defer os.RemoveAll(dir)
+	missing := filepath.Join(dir, "missing")
+
+	code, _, _ := runCmd("stale", newer, "--", older)
+	g.Expect(code).To(Equal(exitFalse))
+
+	code, stdout, _ := runCmd("stale", "-explain", older, "--", newer)
+	g.Expect(code).To(Equal(exitTrue))
+	g.Expect(stdout).To(HavePrefix("relation: all are older\n"))
+	g.Expect(stdout).To(ContainSubstring("not newer:\n  " + older + " "))
+
+	code, stdout, _ = runCmd("stale", "-json", newer, missing, "--", older)
+	g.Expect(code).To(Equal(exitTrue))
+	g.Expect(stdout).To(ContainSubstring(`"result": true`))
+	g.Expect(stdout).To(ContainSubstring(`"path": "` + missing + `"`))
+
+	code, _, stderr := runCmd("stale", newer, "--", missing)
+	g.Expect(code).To(Equal(exitError))
+	g.Expect(stderr).To(Equal("filemod stale: missing input " + missing + "\n"))
+}
+
+func TestLsAndPartition(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir, older, newer := setup(g)
+	defer os.RemoveAll(dir)
+	missing := filepath.Join(dir, "missing")
+
+	code, stdout, _ := runCmd("ls", "-sort", "mtime", newer, older)
+	g.Expect(code).To(Equal(exitTrue))
+	g.Expect(stdout).To(MatchRegexp(`^-rw-r--r--  3  2020-01-01T00:00:00Z  ` + older + "\n-rw"))
+
+	code, _, stderr := runCmd("ls", "-sort", "colour", newer)
+	g.Expect(code).To(Equal(exitError))
+	g.Expect(stderr).To(Equal("filemod ls: unknown sort order \"colour\"\n"))
+
+	code, stdout, _ = runCmd("partition", dir, older, missing)
+	g.Expect(code).To(Equal(exitTrue))
+	g.Expect(stdout).To(MatchRegexp("^files:\n.*" + older + "\ndirectories:\n.*" + dir + "\nabsent:\nabsent +" + missing + "\n$"))
+}
+
+func TestDiff(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir, older, _ := setup(g)
+	defer os.RemoveAll(dir)
+	other, err := ioutil.TempDir("", "filemod")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(other)
+	g.Expect(ioutil.WriteFile(filepath.Join(other, "new"), []byte("newer"), 0644)).To(Succeed())
+	g.Expect(ioutil.WriteFile(filepath.Join(other, "extra"), []byte("extra"), 0644)).To(Succeed())
+
+	code, stdout, _ := runCmd("diff", dir, other)
+	g.Expect(code).To(Equal(exitFalse))
+	g.Expect(stdout).To(Equal("M new\n- old\n+ extra\n"))
+
+	code, stdout, _ = runCmd("diff", dir, dir)
+	g.Expect(code).To(Equal(exitTrue))
+	g.Expect(stdout).To(BeEmpty())
+
+	sub := filepath.Join(dir, "sub")
+	g.Expect(os.Mkdir(sub, 0755)).To(Succeed())
+	g.Expect(ioutil.WriteFile(filepath.Join(sub, "x"), []byte("x"), 0644)).To(Succeed())
+	code, stdout, _ = runCmd("diff", dir, sub)
+	g.Expect(code).To(Equal(exitFalse))
+	g.Expect(stdout).To(Equal("- new\n- old\n- sub\n- sub/x\n+ x\n"))
+
+	missing := filepath.Join(dir, "missing")
+	code, stdout, stderr := runCmd("diff", missing, dir)
+	g.Expect(code).To(Equal(exitError))
+	g.Expect(stdout).To(BeEmpty())
+	g.Expect(stderr).To(Equal("filemod diff: diff " + missing + ": file does not exist\n"))
+
+	code, _, stderr = runCmd("diff", filepath.Join(older, "x"), dir)
+	g.Expect(code).To(Equal(exitError))
+	g.Expect(stderr).To(ContainSubstring("not a directory"))
+}
+
+func TestUnknownCommand(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	code, _, stderr := runCmd("frobnicate")
+	g.Expect(code).To(Equal(exitError))
+	g.Expect(stderr).To(HavePrefix("filemod: unknown command \"frobnicate\"\n"))
+}
+
+func TestRun(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir, older, newer := setup(g)
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "out")
+
+	code, _, _ := runCmd("run", "-o", newer, "-i", older, "--", "false")
+	g.Expect(code).To(Equal(exitTrue))
+
+	code, _, stderr := runCmd("run", "-o", out, "-i", older, newer, "--", "false")
+	g.Expect(code).To(Equal(1))
+	g.Expect(stderr).To(Equal("filemod run: false: exit status 1\n"))
+
+	code, _, stderr = runCmd("run", "-o", out, "-i", older, "--", "true")
+	g.Expect(code).To(Equal(exitError))
+	g.Expect(stderr).To(Equal("filemod run: run " + out + ": output was not refreshed\n"))
+
+	code, _, _ = runCmd("run", "-o", out, "-i", older, "--", "touch", out)
+	g.Expect(code).To(Equal(exitTrue))
+	g.Expect(out).To(BeAnExistingFile())
+
+	code, _, stderr = runCmd("run", older, "--", "true")
+	g.Expect(code).To(Equal(exitError))
+	g.Expect(stderr).To(Equal("filemod run: expected -o OUT... -i IN... -- COMMAND [ARG...]\n"))
+}
+
+func TestLsFormats(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir, older, _ := setup(g)
+	defer os.RemoveAll(dir)
+
+	code, stdout, _ := runCmd("ls", "-csv", "-columns", "path,size", older)
+	g.Expect(code).To(Equal(exitTrue))
+	g.Expect(stdout).To(Equal("path,size\n" + older + ",3\n"))
+
+	code, stdout, _ = runCmd("ls", "-json", older)
+	g.Expect(code).To(Equal(exitTrue))
+	g.Expect(stdout).To(ContainSubstring(`"modTime": "2020-01-01T00:00:00Z"`))
+
+	code, _, stderr := runCmd("ls", "-columns", "colour", older)
+	g.Expect(code).To(Equal(exitError))
+	g.Expect(stderr).To(Equal("filemod ls: unknown column \"colour\"\n"))
+}
@@ -0,0 +1,171 @@
+package filemod
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+func (c comparison) String() string {
+	switch c {
+	case allAreNewer:
+		return "all are newer"
+	case overlapping:
+		return "overlapping"
+	case allAreOlder:
+		return "all are older"
+	}
+	return "undefined"
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// ComparisonReport explains how the modification timestamps of two lists of files
+// compare. It holds the same outcome as AllAreOlderThan, OverlapsWith and AllAreNewerThan,
+// together with the evidence behind it.
+type ComparisonReport struct {
+	relation comparison
+
+	// Files and Other are the two lists that were compared, both sorted by modification time.
+	Files, Other Files
+
+	// OverlapStart and OverlapEnd bound the interval within which the modification
+	// times of the two lists overlap. Both are zero if there is no overlap.
+	OverlapStart, OverlapEnd time.Time
+}
+
+// Compare compares the file modification timestamps of two lists of files, returning
+// a detailed report. Like AllAreOlderThan etc, both lists are sorted as a side effect.
+func (files Files) Compare(other Files) ComparisonReport {
+	report := ComparisonReport{
+		relation: files.compare(other),
+		Files:    files,
+		Other:    other,
+	}
+
+	if report.relation == overlapping {
+		report.OverlapStart = latest(files[0].ModTime(), other[0].ModTime())
+		report.OverlapEnd = earliest(files[len(files)-1].ModTime(), other[len(other)-1].ModTime())
+	}
+
+	return report
+}
+
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func earliest(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// AllAreOlder returns true if all of 'Files' are older than all of 'Other'.
+func (r ComparisonReport) AllAreOlder() bool {
+	return r.relation == allAreOlder
+}
+
+// Overlaps returns true if the ranges of modification times of the two lists overlap.
+func (r ComparisonReport) Overlaps() bool {
+	return r.relation == overlapping
+}
+
+// AllAreNewer returns true if all of 'Files' are newer than all of 'Other'.
+func (r ComparisonReport) AllAreNewer() bool {
+	return r.relation == allAreNewer
+}
+
+// Undefined returns true if either list was empty, so no comparison was possible.
+func (r ComparisonReport) Undefined() bool {
+	return r.relation == undefined
+}
+
+// NotOlder returns the files that prevent 'Files' being all older than 'Other'.
+// These are the members of 'Files' that are not older than the oldest of 'Other',
+// followed by the members of 'Other' that are not newer than the newest of 'Files'.
+func (r ComparisonReport) NotOlder() Files {
+	if r.relation == undefined {
+		return nil
+	}
+	oldestOther := r.Other[0].ModTime()
+	newestFiles := r.Files[len(r.Files)-1].ModTime()
+
+	violations := r.Files.Filter(func(f FileMetaInfo) bool {
+		return !f.ModTime().Before(oldestOther)
+	})
+	return append(violations, r.Other.Filter(func(f FileMetaInfo) bool {
+		return !newestFiles.Before(f.ModTime())
+	})...)
+}
+
+// NotNewer returns the files that prevent 'Files' being all newer than 'Other'.
+// These are the members of 'Files' that are not newer than the newest of 'Other',
+// followed by the members of 'Other' that are not older than the oldest of 'Files'.
+func (r ComparisonReport) NotNewer() Files {
+	if r.relation == undefined {
+		return nil
+	}
+	newestOther := r.Other[len(r.Other)-1].ModTime()
+	oldestFiles := r.Files[0].ModTime()
+
+	violations := r.Files.Filter(func(f FileMetaInfo) bool {
+		return !newestOther.Before(f.ModTime())
+	})
+	return append(violations, r.Other.Filter(func(f FileMetaInfo) bool {
+		return !f.ModTime().Before(oldestFiles)
+	})...)
+}
+
+// String renders the report as readable text, for example to explain a rebuild decision.
+func (r ComparisonReport) String() string {
+	buf := &strings.Builder{}
+	fmt.Fprintf(buf, "relation: %s\n", r.relation)
+	writeRange(buf, "files", r.Files)
+	writeRange(buf, "other", r.Other)
+
+	if r.relation == overlapping {
+		fmt.Fprintf(buf, "overlap: %s to %s\n", formatTime(r.OverlapStart), formatTime(r.OverlapEnd))
+	}
+
+	if r.relation != allAreOlder {
+		writeViolations(buf, "not older", r.NotOlder())
+	}
+
+	if r.relation != allAreNewer {
+		writeViolations(buf, "not newer", r.NotNewer())
+	}
+
+	return buf.String()
+}
+
+func writeRange(buf *strings.Builder, label string, files Files) {
+	if len(files) == 0 {
+		fmt.Fprintf(buf, "%s: none\n", label)
+		return
+	}
+	oldest, newest := files[0], files[len(files)-1]
+	fmt.Fprintf(buf, "%s: %d, oldest %s (%s), newest %s (%s)\n", label, len(files),
+		oldest.Path(), formatTime(oldest.ModTime()), newest.Path(), formatTime(newest.ModTime()))
+}
+
+func writeViolations(buf *strings.Builder, label string, files Files) {
+	if len(files) == 0 {
+		return
+	}
+	fmt.Fprintf(buf, "%s:\n", label)
+	for _, f := range files {
+		fmt.Fprintf(buf, "  %s (%s)\n", f.Path(), formatTime(f.ModTime()))
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "absent"
+	}
+	return t.Format(time.RFC3339Nano)
+}
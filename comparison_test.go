@@ -0,0 +1,94 @@
+package filemod
+
+import (
+	. "github.com/onsi/gomega"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCompareOverlapping(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	a1 := fileInfo{name: "a1", modTime: now.Add(-3 * time.Minute)}
+	a2 := fileInfo{name: "a2", modTime: now.Add(-1 * time.Minute)}
+	b1 := fileInfo{name: "b1", modTime: now.Add(-2 * time.Minute)}
+	b2 := fileInfo{name: "b2", modTime: now}
+	fs = &osStub{[]fileInfo{a1, a2, b1, b2}} // global
+	g1 := New("/a1", "/a2")
+	g2 := New("/b1", "/b2")
+
+	// When...
+	r := g1.Compare(g2)
+
+	// Then...
+	g.Expect(r.Overlaps()).To(BeTrue())
+	g.Expect(r.AllAreOlder()).To(BeFalse())
+	g.Expect(r.AllAreNewer()).To(BeFalse())
+	g.Expect(r.Undefined()).To(BeFalse())
+	g.Expect(r.OverlapStart).To(Equal(b1.modTime))
+	g.Expect(r.OverlapEnd).To(Equal(a2.modTime))
+	g.Expect(paths(r.NotOlder())).To(Equal([]string{"/a2", "/b1"}))
+	g.Expect(paths(r.NotNewer())).To(Equal([]string{"/a1", "/a2", "/b1", "/b2"}))
+	g.Expect(r.String()).To(Equal(
+		"relation: overlapping\n" +
+			"files: 2, oldest /a1 (2020-01-01T11:57:00Z), newest /a2 (2020-01-01T11:59:00Z)\n" +
+			"other: 2, oldest /b1 (2020-01-01T11:58:00Z), newest /b2 (2020-01-01T12:00:00Z)\n" +
+			"overlap: 2020-01-01T11:58:00Z to 2020-01-01T11:59:00Z\n" +
+			"not older:\n" +
+			"  /a2 (2020-01-01T11:59:00Z)\n" +
+			"  /b1 (2020-01-01T11:58:00Z)\n" +
+			"not newer:\n" +
+			"  /a1 (2020-01-01T11:57:00Z)\n" +
+			"  /a2 (2020-01-01T11:59:00Z)\n" +
+			"  /b1 (2020-01-01T11:58:00Z)\n" +
+			"  /b2 (2020-01-01T12:00:00Z)\n"))
+}
+
+func TestCompareOlder(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	a1 := fileInfo{name: "a1", modTime: now.Add(-3 * time.Minute)}
+	x := fileInfo{err: os.ErrNotExist}
+	b1 := fileInfo{name: "b1", modTime: now}
+	fs = &osStub{[]fileInfo{a1, x, b1}} // global
+	g1 := New("/a1", "/x")
+	g2 := New("/b1")
+
+	// When...
+	r := g1.Compare(g2)
+
+	// Then...
+	g.Expect(r.AllAreOlder()).To(BeTrue())
+	g.Expect(r.OverlapStart.IsZero()).To(BeTrue())
+	g.Expect(r.NotOlder()).To(BeEmpty())
+	g.Expect(r.String()).To(Equal(
+		"relation: all are older\n" +
+			"files: 2, oldest /x (absent), newest /a1 (2020-01-01T11:57:00Z)\n" +
+			"other: 1, oldest /b1 (2020-01-01T12:00:00Z), newest /b1 (2020-01-01T12:00:00Z)\n" +
+			"not newer:\n" +
+			"  /x (absent)\n" +
+			"  /a1 (2020-01-01T11:57:00Z)\n" +
+			"  /b1 (2020-01-01T12:00:00Z)\n"))
+}
+
+func TestCompareEmpty(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = &osStub{} // global; nothing exists
+	g1 := New("/a1")
+
+	// When...
+	r := g1.Compare(Of())
+
+	// Then...
+	g.Expect(r.Undefined()).To(BeTrue())
+	g.Expect(r.NotOlder()).To(BeNil())
+	g.Expect(r.NotNewer()).To(BeNil())
+	g.Expect(r.String()).To(Equal(
+		"relation: undefined\n" +
+			"files: 1, oldest /a1 (absent), newest /a1 (absent)\n" +
+			"other: none\n"))
+}
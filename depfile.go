@@ -0,0 +1,151 @@
+package filemod
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// DepRule is one rule from a Makefile depfile, stating that the targets depend on the
+// sources. Rules with no sources are the phony rules emitted by 'gcc -MP'.
+type DepRule struct {
+	Targets Files
+	Sources Files
+}
+
+// UpToDate tests whether all of the targets are newer than all of the sources. As with
+// make, a rule with no sources is up to date if all of its targets exist. A rule with no
+// targets is never up to date. The order of the rule's lists is not changed.
+func (rule DepRule) UpToDate() bool {
+	if len(rule.Targets) == 0 || len(rule.Targets.AbsentOnly()) > 0 {
+		return false
+	}
+	if len(rule.Sources) == 0 {
+		return true
+	}
+	targets := append(Files(nil), rule.Targets...)
+	sources := append(Files(nil), rule.Sources...)
+	return targets.AllAreNewerThan(sources)
+}
+
+// ReadDepfile reads a Makefile depfile, such as those produced by 'gcc -MD'. Relative
+// paths are treated as relative to the directory containing the depfile.
+func ReadDepfile(name string) ([]DepRule, error) {
+	data, err := readFile(fs, name)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := ParseDepfile(bytes.NewReader(data), filepath.Dir(name))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return rules, nil
+}
+
+// ParseDepfile parses a Makefile depfile, such as those produced by 'gcc -MD'. Each rule
+// may have several targets. Lines may be continued with a backslash; spaces and '#' in
+// names are escaped with a backslash, and '$' is written as '$$'. Comments are ignored.
+// A colon separates the targets from the sources only if it is followed by white space,
+// so Windows paths such as 'C:\src\a.c' are allowed.
+//
+// Relative paths are joined to dir, unless it is empty. The files are tested as they are
+// parsed, so that the rules can be used for comparisons directly.
+func ParseDepfile(r io.Reader, dir string) ([]DepRule, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []DepRule
+	var words, targets []string
+	var word strings.Builder
+	seenColon := false
+	line, ruleLine := 1, 1
+
+	endWord := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+
+	endRule := func() error {
+		endWord()
+		if !seenColon {
+			if len(words) > 0 {
+				return fmt.Errorf("line %d: missing ':' after %q", ruleLine, words[0])
+			}
+			return nil
+		}
+		rules = append(rules, DepRule{Targets: New(joinAll(dir, targets)...), Sources: New(joinAll(dir, words)...)})
+		words, targets, seenColon = nil, nil, false
+		return nil
+	}
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		next := byte(0)
+		if i+1 < len(data) {
+			next = data[i+1]
+		}
+
+		switch {
+		case c == '\\' && next == '\n':
+			endWord()
+			i++
+			line++
+		case c == '\\' && next == '\r' && i+2 < len(data) && data[i+2] == '\n':
+			endWord()
+			i += 2
+			line++
+		case c == '\\' && (next == ' ' || next == '#'):
+			word.WriteByte(next)
+			i++
+		case c == '$' && next == '$':
+			word.WriteByte('$')
+			i++
+		case c == '#':
+			for i+1 < len(data) && data[i+1] != '\n' {
+				i++
+			}
+		case c == '\n':
+			if err := endRule(); err != nil {
+				return nil, err
+			}
+			line++
+			ruleLine = line
+		case c == ' ' || c == '\t' || c == '\r':
+			endWord()
+		case c == ':' && !seenColon && (next == 0 || next == ' ' || next == '\t' || next == '\r' || next == '\n'):
+			endWord()
+			targets, words = words, nil
+			seenColon = true
+		default:
+			word.WriteByte(c)
+		}
+	}
+
+	if err := endRule(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func joinAll(dir string, names []string) []string {
+	if dir == "" {
+		return names
+	}
+
+	result := make([]string, len(names))
+	for i, name := range names {
+		if filepath.IsAbs(name) {
+			result[i] = name
+		} else {
+			result[i] = filepath.Join(dir, name)
+		}
+	}
+	return result
+}
@@ -0,0 +1,89 @@
+package filemod
+
+import (
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const depfileText = "# generated by the compiler\n" +
+	"obj/a.o obj/a.d: src/a.c include/my\\ header.h \\\n" +
+	"  include/b.h \\\r\n" +
+	"  C:\\sdk\\x.h\n" +
+	"\n" +
+	"include/my\\ header.h:\n" +
+	"include/b.h:\n" +
+	"$$weird\\#name.o : src/a.c # trailing comment\n"
+
+func TestParseDepfile(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	dir := tempTree(g, "src/a.c", "include/my header.h", "include/b.h")
+	defer os.RemoveAll(dir)
+
+	// When...
+	rules, err := ParseDepfile(strings.NewReader(depfileText), dir)
+
+	// Then...
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(rules).To(HaveLen(4))
+	g.Expect(relPaths(dir, rules[0].Targets)).To(Equal([]string{"obj/a.o", "obj/a.d"}))
+	g.Expect(relPaths(dir, rules[0].Sources)).To(Equal([]string{"src/a.c", "include/my header.h", "include/b.h", `C:\sdk\x.h`}))
+	g.Expect(rules[0].Sources[:3].AbsentOnly()).To(BeEmpty())
+	g.Expect(relPaths(dir, rules[1].Targets)).To(Equal([]string{"include/my header.h"}))
+	g.Expect(rules[1].Sources).To(BeEmpty())
+	g.Expect(relPaths(dir, rules[3].Targets)).To(Equal([]string{"$weird#name.o"}))
+	g.Expect(relPaths(dir, rules[3].Sources)).To(Equal([]string{"src/a.c"}))
+}
+
+func TestReadDepfile(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	dir := tempTree(g, "a.c", "a.h", "a.o")
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "a.d")
+	g.Expect(ioutil.WriteFile(name, []byte("a.o: a.c a.h\n"), 0644)).To(Succeed())
+	older := time.Now().Add(-time.Hour)
+	g.Expect(os.Chtimes(filepath.Join(dir, "a.c"), older, older)).To(Succeed())
+	g.Expect(os.Chtimes(filepath.Join(dir, "a.h"), older, older)).To(Succeed())
+
+	// When...
+	rules, err := ReadDepfile(name)
+	g.Expect(err).NotTo(HaveOccurred())
+	upToDate := rules[0].UpToDate()
+
+	later := time.Now().Add(time.Hour)
+	g.Expect(os.Chtimes(filepath.Join(dir, "a.h"), later, later)).To(Succeed())
+	rules, err = ReadDepfile(name)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// Then...
+	g.Expect(upToDate).To(BeTrue())
+	g.Expect(rules[0].UpToDate()).To(BeFalse())
+}
+
+func TestUpToDateEdgeCases(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	dir := tempTree(g, "a.o", "x.c", "y.c")
+	defer os.RemoveAll(dir)
+	older := time.Now().Add(-time.Hour)
+	g.Expect(os.Chtimes(filepath.Join(dir, "y.c"), older, older)).To(Succeed())
+	present := New(filepath.Join(dir, "a.o"))
+	absent := New(filepath.Join(dir, "b.o"))
+	sources := New(filepath.Join(dir, "x.c"), filepath.Join(dir, "y.c"))
+
+	// Then...
+	g.Expect(DepRule{Targets: present}.UpToDate()).To(BeTrue())
+	g.Expect(DepRule{Targets: absent}.UpToDate()).To(BeFalse())
+	g.Expect(DepRule{Sources: present}.UpToDate()).To(BeFalse())
+	g.Expect(DepRule{Targets: absent, Sources: sources}.UpToDate()).To(BeFalse())
+	g.Expect(relPaths(dir, sources)).To(Equal([]string{"x.c", "y.c"}))
+}
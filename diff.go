@@ -0,0 +1,131 @@
+package filemod
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ChangeKind enumerates the ways in which a file can differ between two snapshots.
+type ChangeKind int
+
+const (
+	Added ChangeKind = iota + 1
+	Removed
+	Modified
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Modified:
+		return "modified"
+	}
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+// Change describes one file that differs between two snapshots. Before is absent
+// (or zero) for added files; After is absent (or zero) for removed files.
+type Change struct {
+	Kind   ChangeKind
+	Key    string
+	Before FileMetaInfo
+	After  FileMetaInfo
+}
+
+// Diff compares two snapshots of a group of files, for example before and after
+// some build step. Files are matched using the key; if key is nil, CleanPath is used.
+// Use RelativeTo or DiffRelative to compare trees that have different roots.
+//
+// A file is modified if its type, size, mode or modification time differ. When either
+// modification time is in whole seconds, which is the precision typically recorded by
+// archives, they are compared in whole seconds. Files that do not exist in one snapshot
+// are added or removed.
+// The changes are in the order of 'before', followed by files only in 'after'.
+func Diff(before, after Files, key Key) []Change {
+	key = orDefault(key)
+	return diff(before, after, key, key)
+}
+
+// DiffRelative compares two trees in the same way as Diff, identifying the files in each
+// tree by their path relative to its own root. Unlike RelativeTo, this works when one
+// tree is nested within the other.
+func DiffRelative(before, after Files, beforeRoot, afterRoot string) []Change {
+	return diff(before, after, RelativeTo(beforeRoot), RelativeTo(afterRoot))
+}
+
+func diff(before, after Files, beforeKey, afterKey Key) []Change {
+	afterByKey := make(map[string]FileMetaInfo, len(after))
+	for _, f := range after {
+		afterByKey[afterKey(f)] = f
+	}
+
+	var changes []Change
+	seen := make(keySet, len(before))
+
+	for _, b := range before {
+		k := beforeKey(b)
+		seen[k] = struct{}{}
+		a, exists := afterByKey[k]
+		switch {
+		case b.Exists() && !(exists && a.Exists()):
+			changes = append(changes, Change{Kind: Removed, Key: k, Before: b, After: a})
+		case !b.Exists() && exists && a.Exists():
+			changes = append(changes, Change{Kind: Added, Key: k, Before: b, After: a})
+		case b.Exists() && isModified(b, a):
+			changes = append(changes, Change{Kind: Modified, Key: k, Before: b, After: a})
+		}
+	}
+
+	for _, a := range after {
+		k := afterKey(a)
+		if _, exists := seen[k]; !exists && a.Exists() {
+			seen[k] = struct{}{}
+			changes = append(changes, Change{Kind: Added, Key: k, After: a})
+		}
+	}
+
+	return changes
+}
+
+func isModified(before, after FileMetaInfo) bool {
+	if before.IsDir() != after.IsDir() {
+		return true
+	}
+	if before.IsDir() {
+		// directory sizes and timestamps only reflect their entries
+		return before.Mode() != after.Mode()
+	}
+	return before.Size() != after.Size() ||
+		before.Mode() != after.Mode() ||
+		!sameModTime(before.ModTime(), after.ModTime())
+}
+
+func sameModTime(a, b time.Time) bool {
+	if a.Nanosecond() == 0 || b.Nanosecond() == 0 {
+		// one side has only second precision, such as an archive member
+		return a.Truncate(time.Second).Equal(b.Truncate(time.Second))
+	}
+	return a.Equal(b)
+}
+
+// RelativeTo returns a Key that identifies files by their path relative to the first
+// of the root directories that contains them. This allows files in different trees
+// to be matched. Files not beneath any root are identified by their cleaned path.
+// When one root contains another, the files beneath both cannot be told apart; use
+// DiffRelative instead.
+func RelativeTo(roots ...string) Key {
+	return func(file FileMetaInfo) string {
+		for _, root := range roots {
+			rel, err := filepath.Rel(root, file.path)
+			if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return rel
+			}
+		}
+		return CleanPath(file)
+	}
+}
@@ -0,0 +1,103 @@
+package filemod
+
+import (
+	. "github.com/onsi/gomega"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDiff(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	now := time.Now().UTC()
+	a := fileInfo{name: "a", size: 1, modTime: now}
+	b := fileInfo{name: "b", size: 2, modTime: now}
+	c := fileInfo{name: "c", size: 3, modTime: now}
+	b2 := fileInfo{name: "b", size: 2, modTime: now.Add(time.Second)}
+	c2 := fileInfo{name: "c", size: 3, modTime: now}
+	d := fileInfo{name: "d", size: 4, modTime: now}
+	x := fileInfo{err: os.ErrNotExist}
+	fs = &osStub{[]fileInfo{a, b, c, x, b2, c2, d, x}} // global
+	before := New("/1/a", "/1/b", "/1/c", "/1/e")
+	after := New("/2/b", "/2/c", "/2/d", "/2/a")
+
+	// When...
+	changes := Diff(before, after, RelativeTo("/1", "/2"))
+
+	// Then...
+	g.Expect(changes).To(HaveLen(3))
+	g.Expect(changes[0].Kind).To(Equal(Removed))
+	g.Expect(changes[0].Key).To(Equal("a"))
+	g.Expect(changes[1].Kind).To(Equal(Modified))
+	g.Expect(changes[1].Key).To(Equal("b"))
+	g.Expect(changes[1].Before.Path()).To(Equal("/1/b"))
+	g.Expect(changes[1].After.Path()).To(Equal("/2/b"))
+	g.Expect(changes[2].Kind).To(Equal(Added))
+	g.Expect(changes[2].Key).To(Equal("d"))
+	g.Expect(changes[2].After.Path()).To(Equal("/2/d"))
+	g.Expect(changes[0].Kind.String()).To(Equal("removed"))
+}
+
+func TestDiffSame(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	now := time.Now().UTC()
+	a := fileInfo{name: "a", size: 1, modTime: now}
+	x := fileInfo{err: os.ErrNotExist}
+	fs = &osStub{[]fileInfo{a, x}} // global
+	before := New("/a", "/x")
+	fs = &osStub{[]fileInfo{a, x}} // global
+	after := New("/a", "/x")
+
+	// When...
+	changes := Diff(before, after, nil)
+
+	// Then...
+	g.Expect(changes).To(BeEmpty())
+}
+
+func TestDiffRelativeNested(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	now := time.Now().UTC()
+	d := fileInfo{name: "d", size: 1, modTime: now}
+	x := fileInfo{name: "x", size: 2, modTime: now}
+	fs = &osStub{[]fileInfo{d, x, x}} // global
+	before := New("/d/d", "/d/sub/x")
+	after := New("/d/sub/x")
+
+	// When...
+	changes := DiffRelative(before, after, "/d", "/d/sub")
+
+	// Then...
+	g.Expect(changes).To(HaveLen(3))
+	g.Expect(changes[0].Kind).To(Equal(Removed))
+	g.Expect(changes[0].Key).To(Equal("d"))
+	g.Expect(changes[1].Kind).To(Equal(Removed))
+	g.Expect(changes[1].Key).To(Equal("sub/x"))
+	g.Expect(changes[2].Kind).To(Equal(Added))
+	g.Expect(changes[2].Key).To(Equal("x"))
+	g.Expect(changes[2].After.Path()).To(Equal("/d/sub/x"))
+}
+
+func TestDiffSubSecond(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	then := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	a := fileInfo{name: "a", size: 1, modTime: then.Add(100 * time.Millisecond)}
+	b := fileInfo{name: "b", size: 1, modTime: then}
+	a2 := fileInfo{name: "a", size: 1, modTime: then.Add(200 * time.Millisecond)}
+	b2 := fileInfo{name: "b", size: 1, modTime: then.Add(300 * time.Millisecond)}
+	fs = &osStub{[]fileInfo{a, b, a2, b2}} // global
+	before := New("/a", "/b")
+	after := New("/a", "/b")
+
+	// When...
+	changes := Diff(before, after, nil)
+
+	// Then...
+	g.Expect(changes).To(HaveLen(1))
+	g.Expect(changes[0].Kind).To(Equal(Modified))
+	g.Expect(changes[0].Key).To(Equal("/a"))
+}
@@ -6,4 +6,28 @@
 // Lists of files can be sorted by modification time, by file size and by path order.
 //
 // Lists of files can be partitioned into files, directories, and absent items.
+//
+// Lists of files can be combined as sets (union, intersection, difference), keyed by
+// path, by inode or by content.
+//
+// Retention policies select old files for deletion, for example from build caches.
+//
+// Directory trees can be walked, compared with each other, and summarised like 'du'.
+// The members of tar and zip archives can be treated in the same way as files on disk.
+//
+// Huge trees can be processed one file at a time as a Stream, an iterator that supports
+// filtering, partitioning and summarising without building a list of every file.
+// Walks can skip the files that git ignores, using an Ignorer.
+// Modification times can instead be taken from a git repository, using WithGitTimes.
+// For reproducible builds, times can be clamped to SOURCE_DATE_EPOCH and audited.
+// Checksum manifests compatible with sha256sum and b2sum can be written and verified.
+// Stamp files record fingerprints of the inputs of build steps, so changes are seen
+// even when files are replaced by older ones.
+// Makefile depfiles can be parsed into rules with target and source files.
+// Generated files can be written atomically, only when their content changes.
+// One tree can be mirrored to another using a SyncPlan, which can be inspected first.
+// File modes can be audited for security problems and fixed in bulk.
+// Resources on web servers can be compared with local files, using HTTP.
+// Generated content can be served with Last-Modified and ETag headers derived from its sources.
+// Directories can be compared using the newest modification times within their subtrees.
 package filemod
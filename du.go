@@ -0,0 +1,165 @@
+package filemod
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DiskUsage is a node in a tree of files and directories, holding the totals for the
+// subtree beneath it, similar to 'du'.
+type DiskUsage struct {
+	// File is the file or directory at this node.
+	File FileMetaInfo
+	// Size is the total size of this node and everything beneath it.
+	Size int64
+	// Count is the number of files (but not directories) at and beneath this node.
+	Count int
+	// Newest is the newest modification time at or beneath this node.
+	Newest time.Time
+	// Children holds the nodes for the contents of a directory.
+	Children []*DiskUsage
+}
+
+// NewDiskUsage builds disk-usage trees from a list of files, typically obtained from
+// Walk, although they may be in any order. Each file is attached to the node for its
+// parent directory, if that is in the list; the remaining files are the roots of the
+// trees, which are returned.
+//
+// If allocated is true, sizes are the space allocated on disk (where the platform
+// provides this), otherwise they are the apparent sizes.
+func NewDiskUsage(files Files, allocated bool) []*DiskUsage {
+	nodes := make(map[string]*DiskUsage, len(files))
+	list := make([]*DiskUsage, len(files))
+
+	for i, f := range files {
+		node := &DiskUsage{File: f, Size: sizeOf(f, allocated), Newest: f.ModTime()}
+		if f.Exists() && !f.IsDir() {
+			node.Count = 1
+		}
+		nodes[filepath.Clean(f.path)] = node
+		list[i] = node
+	}
+
+	var roots []*DiskUsage
+	for _, node := range list {
+		if parent, exists := nodes[filepath.Dir(filepath.Clean(node.File.path))]; exists && parent != node {
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+
+	for _, root := range roots {
+		root.total()
+	}
+
+	return roots
+}
+
+func sizeOf(f FileMetaInfo, allocated bool) int64 {
+	if allocated && f.Exists() {
+		if n, ok := blocksOf(f.fi); ok {
+			return n
+		}
+	}
+	return f.Size()
+}
+
+func (du *DiskUsage) total() {
+	for _, c := range du.Children {
+		c.total()
+		du.Size += c.Size
+		du.Count += c.Count
+		if c.Newest.After(du.Newest) {
+			du.Newest = c.Newest
+		}
+	}
+}
+
+// Summary gets the file information for this node, except that its size and modification
+// time are the totals for the subtree.
+func (du *DiskUsage) Summary() FileMetaInfo {
+	if du.File.fi == nil {
+		return du.File
+	}
+	return FileMetaInfo{
+		path: du.File.path,
+		err:  du.File.err,
+		fi:   usageInfo{FileInfo: du.File.fi, size: du.Size, newest: du.Newest},
+		fs:   du.File.fs,
+	}
+}
+
+// Subtrees gets the summaries of the children of this node.
+func (du *DiskUsage) Subtrees() Files {
+	result := make(Files, len(du.Children))
+	for i, c := range du.Children {
+		result[i] = c.Summary()
+	}
+	return result
+}
+
+// SortedBy rearranges the children of this node, and of all the nodes beneath it, using
+// one of the sort methods of Files applied to their summaries. For example,
+//
+//	du.SortedBy(Files.SortedBySize)
+//
+// orders every subtree by total size. It returns the modified node.
+func (du *DiskUsage) SortedBy(sorter func(Files) Files) *DiskUsage {
+	byPath := make(map[string]*DiskUsage, len(du.Children))
+	for _, c := range du.Children {
+		byPath[c.File.path] = c
+	}
+
+	for i, f := range sorter(du.Subtrees()) {
+		du.Children[i] = byPath[f.path].SortedBy(sorter)
+	}
+
+	return du
+}
+
+// WriteTo writes an indented report of the tree, showing the size, count, newest
+// modification time and name of each node.
+func (du *DiskUsage) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, du.String())
+	return int64(n), err
+}
+
+func (du *DiskUsage) write(buf *strings.Builder, depth int) {
+	name := du.File.path
+	if depth > 0 {
+		name = filepath.Base(name)
+	}
+	fmt.Fprintf(buf, "%12d %8d  %s  %s%s\n", du.Size, du.Count, formatTime(du.Newest), strings.Repeat("  ", depth), name)
+	for _, c := range du.Children {
+		c.write(buf, depth+1)
+	}
+}
+
+// String renders the tree as an indented report.
+func (du *DiskUsage) String() string {
+	buf := &strings.Builder{}
+	du.write(buf, 0)
+	return buf.String()
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// usageInfo overrides the size and modification time of a file with subtree totals.
+type usageInfo struct {
+	os.FileInfo
+	size   int64
+	newest time.Time
+}
+
+func (ui usageInfo) Size() int64 {
+	return ui.size
+}
+
+func (ui usageInfo) ModTime() time.Time {
+	return ui.newest
+}
@@ -0,0 +1,99 @@
+package filemod
+
+import (
+	"bytes"
+	. "github.com/onsi/gomega"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDiskUsage(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := fileInfo{name: "r", size: 10, modTime: now.Add(-9 * time.Hour), isDir: true}
+	a := fileInfo{name: "a", size: 10, modTime: now.Add(-8 * time.Hour), isDir: true}
+	a1 := fileInfo{name: "a1", size: 100, modTime: now.Add(-3 * time.Hour)}
+	a2 := fileInfo{name: "a2", size: 200, modTime: now.Add(-4 * time.Hour)}
+	b := fileInfo{name: "b", size: 10, modTime: now.Add(-7 * time.Hour), isDir: true}
+	b1 := fileInfo{name: "b1", size: 1000, modTime: now.Add(-5 * time.Hour)}
+	c := fileInfo{name: "c", size: 5, modTime: now.Add(-1 * time.Hour)}
+	fs = &osStub{[]fileInfo{r, a, a1, a2, b, b1, c}} // global
+	files := New("/r", "/r/a", "/r/a/1", "/r/a/2", "/r/b", "/r/b/1", "/r/c")
+
+	// When...
+	roots := NewDiskUsage(files, false)
+
+	// Then...
+	g.Expect(roots).To(HaveLen(1))
+	root := roots[0]
+	g.Expect(root.Size).To(BeEquivalentTo(1335))
+	g.Expect(root.Count).To(Equal(4))
+	g.Expect(root.Newest).To(Equal(c.modTime))
+	g.Expect(root.Children).To(HaveLen(3))
+	g.Expect(root.Children[0].Size).To(BeEquivalentTo(310))
+	g.Expect(root.Children[0].Newest).To(Equal(a1.modTime))
+	g.Expect(root.Summary().Size()).To(BeEquivalentTo(1335))
+	g.Expect(root.Summary().ModTime()).To(Equal(c.modTime))
+	g.Expect(root.Summary().IsDir()).To(BeTrue())
+
+	// When...
+	root.SortedBy(Files.SortedBySize)
+	buf := &bytes.Buffer{}
+	root.WriteTo(buf)
+
+	// Then...
+	g.Expect(paths(root.Subtrees())).To(Equal([]string{"/r/c", "/r/a", "/r/b"}))
+	g.Expect(buf.String()).To(Equal(
+		"        1335        4  2020-01-01T11:00:00Z  /r\n" +
+			"           5        1  2020-01-01T11:00:00Z    c\n" +
+			"         310        2  2020-01-01T09:00:00Z    a\n" +
+			"         100        1  2020-01-01T09:00:00Z      1\n" +
+			"         200        1  2020-01-01T08:00:00Z      2\n" +
+			"        1010        1  2020-01-01T07:00:00Z    b\n" +
+			"        1000        1  2020-01-01T07:00:00Z      1\n"))
+}
+
+func TestDiskUsageAllocated(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	dir := tempTree(g, "a", "b/c")
+	defer os.RemoveAll(dir)
+	files, err := Walk(dir)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// When...
+	apparent := NewDiskUsage(files, false)
+	allocated := NewDiskUsage(files, true)
+
+	// Then...
+	g.Expect(apparent).To(HaveLen(1))
+	g.Expect(apparent[0].Count).To(Equal(2))
+	g.Expect(apparent[0].Children[1].File.Path()).To(Equal(filepath.Join(dir, "b")))
+	g.Expect(allocated[0].Size).NotTo(Equal(apparent[0].Size))
+}
+
+func TestDiskUsageOutOfOrder(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	mem := NewMemFS(now)
+	g.Expect(mem.WriteFile("/r/a/1", []byte("12345"), 0644)).To(Succeed())
+	g.Expect(mem.MkdirAll("/r/b", 0755)).To(Succeed())
+	files := NewWith(mem, "/r/a/1", "/r/a", "/r/b", "/r")
+
+	// When...
+	roots := NewDiskUsage(files, false)
+
+	// Then...
+	g.Expect(roots).To(HaveLen(1))
+	root := roots[0]
+	g.Expect(root.File.Path()).To(Equal("/r"))
+	g.Expect(root.Count).To(Equal(1))
+	g.Expect(paths(root.Subtrees())).To(Equal([]string{"/r/a", "/r/b"}))
+	g.Expect(root.Children[0].Size).To(Equal(root.Children[0].File.Size() + 5))
+	g.Expect(root.Summary().statter()).To(BeIdenticalTo(Statter(mem)))
+}
@@ -0,0 +1,112 @@
+package filemod
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// partialHashSize is the number of leading bytes hashed when screening for duplicates.
+const partialHashSize = 4096
+
+// DuplicateGroup holds files that all have identical content.
+type DuplicateGroup struct {
+	// Files holds the duplicates, in path order.
+	Files Files
+	// Size is the size of each file.
+	Size int64
+}
+
+// Wasted gets the number of bytes that would be saved by keeping only one of the files.
+func (d DuplicateGroup) Wasted() int64 {
+	return d.Size * int64(len(d.Files)-1)
+}
+
+// Duplicates finds groups of files that have identical content. To keep this cheap on
+// big trees, files are grouped by size first, then by a hash of their first few
+// kilobytes, and only then by a hash of their whole content.
+//
+// Only regular files are considered; empty files and files that do not exist are
+// ignored. Hard links to the same file are not duplicates; only the first of them is
+// considered. The groups are returned in order of the number of bytes wasted, most
+// first. Files that cannot be read are omitted and the errors are returned as Errors.
+func (files Files) Duplicates() ([]DuplicateGroup, error) {
+	candidates := files.Filter(func(f FileMetaInfo) bool {
+		return f.Exists() && f.Mode().IsRegular() && f.Size() > 0
+	}).Dedupe(Inode)
+
+	var ee Errors
+	var result []DuplicateGroup
+
+	for _, bySize := range groupBy(candidates, func(f FileMetaInfo) (string, error) {
+		return strconv.FormatInt(f.Size(), 10), nil
+	}, &ee) {
+		for _, byPartial := range groupBy(bySize, partialHash, &ee) {
+			full := groupBy(byPartial, func(f FileMetaInfo) (string, error) {
+				if f.Size() <= partialHashSize {
+					return "", nil // the partial hash covered the whole file
+				}
+				return hashFile(f, sha256.New())
+			}, &ee)
+
+			for _, dupes := range full {
+				result = append(result, DuplicateGroup{Files: dupes.SortedByPath(), Size: dupes[0].Size()})
+			}
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Wasted() != result[j].Wasted() {
+			return result[i].Wasted() > result[j].Wasted()
+		}
+		return result[i].Files[0].path < result[j].Files[0].path
+	})
+
+	if len(ee) > 0 {
+		return result, ee
+	}
+	return result, nil
+}
+
+// groupBy splits files into groups that share the same key, returning only the groups
+// with more than one member. Files for which the key cannot be computed are dropped.
+func groupBy(files Files, key func(FileMetaInfo) (string, error), ee *Errors) []Files {
+	var order []string
+	groups := make(map[string]Files)
+
+	for _, f := range files {
+		k, err := key(f)
+		if err != nil {
+			*ee = append(*ee, err)
+			continue
+		}
+		if _, exists := groups[k]; !exists {
+			order = append(order, k)
+		}
+		groups[k] = append(groups[k], f)
+	}
+
+	var result []Files
+	for _, k := range order {
+		if len(groups[k]) > 1 {
+			result = append(result, groups[k])
+		}
+	}
+	return result
+}
+
+func partialHash(f FileMetaInfo) (string, error) {
+	file, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err = io.CopyN(h, file, partialHashSize); err != nil && err != io.EOF {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
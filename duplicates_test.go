@@ -0,0 +1,61 @@
+package filemod
+
+import (
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDuplicates(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	dir := tempTree(g, "empty1", "empty2", "d/")
+	defer os.RemoveAll(dir)
+
+	big := strings.Repeat("x", 2*partialHashSize)
+	contents := map[string]string{
+		"a1": "hello", "a2": "hello", "a3": "hello", "b1": "world",
+		"c1": big + "1", "c2": big + "1", "c3": big + "2",
+	}
+	for name, content := range contents {
+		g.Expect(ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)).To(Succeed())
+	}
+	g.Expect(ioutil.WriteFile(filepath.Join(dir, "empty1"), nil, 0644)).To(Succeed())
+	g.Expect(ioutil.WriteFile(filepath.Join(dir, "empty2"), nil, 0644)).To(Succeed())
+	g.Expect(os.Link(filepath.Join(dir, "b1"), filepath.Join(dir, "b2"))).To(Succeed())
+	g.Expect(os.Link(filepath.Join(dir, "c1"), filepath.Join(dir, "c4"))).To(Succeed())
+
+	files, err := Walk(dir)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// When...
+	groups, err := files.Duplicates()
+
+	// Then...
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(groups).To(HaveLen(2))
+	g.Expect(paths(groups[0].Files)).To(Equal([]string{filepath.Join(dir, "c1"), filepath.Join(dir, "c2")}))
+	g.Expect(groups[0].Wasted()).To(BeEquivalentTo(len(big) + 1))
+	g.Expect(paths(groups[1].Files)).To(Equal([]string{filepath.Join(dir, "a1"), filepath.Join(dir, "a2"), filepath.Join(dir, "a3")}))
+	g.Expect(groups[1].Size).To(BeEquivalentTo(5))
+	g.Expect(groups[1].Wasted()).To(BeEquivalentTo(10))
+}
+
+func TestDuplicatesUnreadable(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = &osStub{[]fileInfo{{name: "a", size: 3}, {name: "b", size: 3}}} // global
+	files := New("/no/such/a", "/no/such/b")
+
+	// When...
+	groups, err := files.Duplicates()
+
+	// Then...
+	g.Expect(groups).To(BeEmpty())
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.(Errors)).To(HaveLen(2))
+}
@@ -0,0 +1,139 @@
+package filemod
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// EffectiveTimes computes the effective modification times of directories. A directory's
+// own modification time changes only when entries are added, removed or renamed, not when
+// the files within it are changed, so comparing directories using it is misleading. The
+// effective modification time of a directory is instead the newest modification time of
+// the directory and everything beneath it. For other files, it is their own modification
+// time.
+//
+// Each subtree is walked once, using the Statter that provided the directory's
+// information, and the effective times of every directory within it are cached. So an
+// EffectiveTimes should be discarded when the files may have changed. It is safe for
+// concurrent use.
+type EffectiveTimes struct {
+	skip []func(FileMetaInfo) bool
+
+	mu    sync.Mutex
+	cache map[string]time.Time
+	errs  Errors
+}
+
+// NewEffectiveTimes creates an EffectiveTimes. Items for which any of the skip predicates
+// returns true are excluded from the subtrees, along with their contents; Ignorer.Ignored
+// is an example.
+func NewEffectiveTimes(skip ...func(FileMetaInfo) bool) *EffectiveTimes {
+	return &EffectiveTimes{
+		skip:  skip,
+		cache: make(map[string]time.Time),
+	}
+}
+
+// ModTime gets the effective modification time of a file.
+func (et *EffectiveTimes) ModTime(file FileMetaInfo) time.Time {
+	if !file.Exists() || !file.IsDir() {
+		return file.ModTime()
+	}
+
+	p := filepath.Clean(file.path)
+	et.mu.Lock()
+	t, exists := et.cache[p]
+	et.mu.Unlock()
+	if exists {
+		return t
+	}
+
+	return et.walk(file)[p]
+}
+
+// walk finds the newest times beneath every directory in the subtree at a root directory,
+// adding them to the cache.
+func (et *EffectiveTimes) walk(root FileMetaInfo) map[string]time.Time {
+	var ee Errors
+	w := Walker{Statter: root.statter(), Skip: et.skipped}
+	list := w.Stream(root.path, func(err error) {
+		ee = append(ee, err)
+	}).Collect()
+
+	p := filepath.Clean(root.path)
+	newest := map[string]time.Time{p: root.ModTime()} // in case the root is skipped
+
+	// directories precede their contents, so going backwards finishes each subtree first
+	for i := len(list) - 1; i >= 0; i-- {
+		p := filepath.Clean(list[i].path)
+		t := list[i].ModTime()
+		if sub, exists := newest[p]; exists && sub.After(t) {
+			t = sub
+		}
+		newest[p] = t
+
+		if i > 0 {
+			parent := filepath.Dir(p)
+			if t.After(newest[parent]) {
+				newest[parent] = t
+			}
+		}
+	}
+
+	et.mu.Lock()
+	defer et.mu.Unlock()
+	for _, f := range list {
+		if f.Exists() && f.IsDir() {
+			p := filepath.Clean(f.path)
+			et.cache[p] = newest[p]
+		}
+	}
+	et.errs = append(et.errs, ee...)
+	return newest
+}
+
+func (et *EffectiveTimes) skipped(file FileMetaInfo) bool {
+	for _, skip := range et.skip {
+		if skip(file) {
+			return true
+		}
+	}
+	return false
+}
+
+// Effective gets the file information for a file, except that the modification time of a
+// directory is its effective modification time. The result can be compared with other
+// files using NewerThan etc. Refreshing it restores the directory's own modification time.
+func (et *EffectiveTimes) Effective(file FileMetaInfo) FileMetaInfo {
+	if !file.Exists() || !file.IsDir() {
+		return file
+	}
+	return FileMetaInfo{
+		path: file.path,
+		err:  file.err,
+		fi:   usageInfo{FileInfo: file.fi, size: file.Size(), newest: et.ModTime(file)},
+		fs:   file.fs,
+	}
+}
+
+// Apply gets the file information for a list of files, with effective modification times
+// for the directories. The result can be sorted using SortedByModTime and compared using
+// AllAreNewerThan etc.
+func (et *EffectiveTimes) Apply(files Files) Files {
+	result := make(Files, len(files))
+	for i, f := range files {
+		result[i] = et.Effective(f)
+	}
+	return result
+}
+
+// Err returns any errors from reading directories so far, as Errors, or nil.
+func (et *EffectiveTimes) Err() error {
+	et.mu.Lock()
+	defer et.mu.Unlock()
+	if len(et.errs) > 0 {
+		return et.errs
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package filemod
+
+import (
+	. "github.com/onsi/gomega"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEffectiveTimes(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	dir := tempTree(g, "src/a", "src/sub/b", "src/tmp/x", "out")
+	defer os.RemoveAll(dir)
+	t0 := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	touch := func(name string, t time.Time) {
+		g.Expect(os.Chtimes(filepath.Join(dir, filepath.FromSlash(name)), t, t)).To(Succeed())
+	}
+	for _, name := range []string{"src/a", "src/sub", "src/tmp", "src"} {
+		touch(name, t0)
+	}
+	touch("out", t0.Add(time.Hour))
+	touch("src/sub/b", t0.Add(2*time.Hour))
+	touch("src/tmp/x", t0.Add(3*time.Hour))
+	src := Lstat(filepath.Join(dir, "src"))
+	out := Lstat(filepath.Join(dir, "out"))
+	isTmp := func(f FileMetaInfo) bool { return f.Name() == "tmp" }
+
+	// When...
+	et := NewEffectiveTimes(isTmp)
+	effective := et.ModTime(src)
+	nested := et.ModTime(Lstat(filepath.Join(dir, "src", "sub")))
+	sorted := et.Apply(New(filepath.Join(dir, "src"), filepath.Join(dir, "out"))).SortedByModTime()
+
+	// Then...
+	g.Expect(effective).To(BeTemporally("==", t0.Add(2*time.Hour)))
+	g.Expect(nested).To(BeTemporally("==", t0.Add(2*time.Hour)))
+	g.Expect(et.ModTime(out)).To(BeTemporally("==", t0.Add(time.Hour)))
+	g.Expect(src.NewerThan(out)).To(BeFalse())
+	g.Expect(et.Effective(src).NewerThan(out)).To(BeTrue())
+	g.Expect(et.Effective(src).IsDir()).To(BeTrue())
+	g.Expect(et.Effective(src).Refresh().ModTime()).To(BeTemporally("==", t0))
+	g.Expect(relPaths(dir, sorted)).To(Equal([]string{"out", "src"}))
+	g.Expect(et.Err()).NotTo(HaveOccurred())
+
+	// When...
+	all := NewEffectiveTimes().ModTime(src)
+
+	// Then...
+	g.Expect(all).To(BeTemporally("==", t0.Add(3*time.Hour)))
+}
+
+func TestEffectiveTimesCached(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	dir := tempTree(g, "d/e/f")
+	defer os.RemoveAll(dir)
+	t0 := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	g.Expect(os.Chtimes(filepath.Join(dir, "d", "e", "f"), t0, t0)).To(Succeed())
+	et := NewEffectiveTimes()
+	before := et.ModTime(Lstat(filepath.Join(dir, "d")))
+
+	// When...
+	t1 := time.Now().Add(time.Hour)
+	g.Expect(os.Chtimes(filepath.Join(dir, "d", "e", "f"), t1, t1)).To(Succeed())
+
+	// Then...
+	g.Expect(et.ModTime(Lstat(filepath.Join(dir, "d", "e")))).To(BeTemporally("==", before))
+	g.Expect(NewEffectiveTimes().ModTime(Lstat(filepath.Join(dir, "d", "e")))).To(BeTemporally("~", t1, time.Second))
+}
@@ -0,0 +1,268 @@
+package filemod
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// fileJSON is the JSON form of FileMetaInfo.
+type fileJSON struct {
+	Path        string     `json:"path"`
+	Exists      bool       `json:"exists"`
+	Name        string     `json:"name,omitempty"`
+	Size        int64      `json:"size,omitempty"`
+	Mode        uint32     `json:"mode,omitempty"`
+	Permissions string     `json:"permissions,omitempty"`
+	ModTime     *time.Time `json:"modTime,omitempty"`
+	IsDir       bool       `json:"isDir,omitempty"`
+	Error       *errorJSON `json:"error,omitempty"`
+}
+
+type errorJSON struct {
+	Op      string `json:"op,omitempty"`
+	Message string `json:"message"`
+}
+
+// MarshalJSON encodes the file information as a JSON object. Files that do not exist
+// have only their path; errors are included.
+func (file FileMetaInfo) MarshalJSON() ([]byte, error) {
+	fj := fileJSON{Path: file.path, Exists: file.Exists()}
+
+	if file.fi != nil {
+		t := file.fi.ModTime()
+		fj.Name = file.fi.Name()
+		fj.Size = file.fi.Size()
+		fj.Mode = uint32(file.fi.Mode())
+		fj.Permissions = file.fi.Mode().String()
+		fj.ModTime = &t
+		fj.IsDir = file.fi.IsDir()
+	}
+
+	if file.err != nil {
+		fj.Error = &errorJSON{Message: file.err.Error()}
+		if pe, ok := file.err.(*os.PathError); ok {
+			fj.Error = &errorJSON{Op: pe.Op, Message: pe.Err.Error()}
+		}
+	}
+
+	return json.Marshal(fj)
+}
+
+// UnmarshalJSON decodes file information encoded by MarshalJSON. The result behaves like
+// the original, except that errors keep only their messages.
+func (file *FileMetaInfo) UnmarshalJSON(data []byte) error {
+	var fj fileJSON
+	if err := json.Unmarshal(data, &fj); err != nil {
+		return err
+	}
+
+	*file = FileMetaInfo{path: fj.Path}
+
+	if fj.ModTime != nil {
+		file.fi = memInfo{name: fj.Name, size: fj.Size, mode: os.FileMode(fj.Mode), modTime: *fj.ModTime}
+	}
+
+	if fj.Error != nil {
+		file.err = errors.New(fj.Error.Message)
+		if fj.Error.Op != "" {
+			file.err = &os.PathError{Op: fj.Error.Op, Path: fj.Path, Err: file.err}
+		}
+	}
+
+	return nil
+}
+
+// WriteJSON writes the files as a JSON array.
+func (files Files) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if files == nil {
+		files = Files{}
+	}
+	return enc.Encode(files)
+}
+
+// WriteJSONLines writes the files as JSON Lines, i.e. one JSON object per line.
+func (files Files) WriteJSONLines(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, f := range files {
+		if err := enc.Encode(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// Column selects one item of file information for CSV and table output.
+type Column string
+
+const (
+	PathColumn    Column = "path"
+	NameColumn    Column = "name"
+	SizeColumn    Column = "size"
+	ModeColumn    Column = "mode"
+	ModTimeColumn Column = "modtime"
+	ErrorColumn   Column = "error"
+)
+
+// DefaultColumns are the columns used when none are specified, similar to 'ls -l'.
+var DefaultColumns = []Column{ModeColumn, SizeColumn, ModTimeColumn, PathColumn}
+
+// Format controls how file information is rendered as text.
+type Format struct {
+	// Columns selects the columns; if empty, DefaultColumns is used.
+	Columns []Column
+	// HumanSizes renders sizes in units such as 'K' and 'M', as in 'ls -h'.
+	HumanSizes bool
+	// RelativeTimes renders modification times as ages, such as '5m ago', according to Now.
+	RelativeTimes bool
+	// TimeLayout is the layout used for modification times; if empty, time.RFC3339 is used.
+	TimeLayout string
+}
+
+// ParseColumns parses a comma-separated list of column names.
+func ParseColumns(s string) ([]Column, error) {
+	var result []Column
+	for _, name := range strings.Split(s, ",") {
+		c := Column(strings.ToLower(strings.TrimSpace(name)))
+		switch c {
+		case PathColumn, NameColumn, SizeColumn, ModeColumn, ModTimeColumn, ErrorColumn:
+			result = append(result, c)
+		default:
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+	}
+	return result, nil
+}
+
+func (format Format) columns() []Column {
+	if len(format.Columns) == 0 {
+		return DefaultColumns
+	}
+	return format.Columns
+}
+
+func (format Format) row(file FileMetaInfo) []string {
+	cols := format.columns()
+	row := make([]string, len(cols))
+	for i, c := range cols {
+		row[i] = format.cell(file, c)
+	}
+	return row
+}
+
+func (format Format) cell(file FileMetaInfo, c Column) string {
+	switch c {
+	case PathColumn:
+		return file.path
+	case NameColumn:
+		return file.Name()
+	case ErrorColumn:
+		if file.err != nil {
+			return file.err.Error()
+		}
+		return ""
+	}
+
+	if !file.Exists() {
+		if c == ModeColumn {
+			return "absent"
+		}
+		return ""
+	}
+
+	switch c {
+	case SizeColumn:
+		if format.HumanSizes {
+			return HumanSize(file.Size())
+		}
+		return strconv.FormatInt(file.Size(), 10)
+	case ModeColumn:
+		return file.Mode().String()
+	case ModTimeColumn:
+		if format.RelativeTimes {
+			return HumanAge(file.Age())
+		}
+		layout := format.TimeLayout
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		return file.ModTime().Format(layout)
+	}
+	return ""
+}
+
+// WriteCSV writes the files as CSV, with a header row naming the columns.
+func (files Files) WriteCSV(w io.Writer, format Format) error {
+	cw := csv.NewWriter(w)
+
+	header := make([]string, len(format.columns()))
+	for i, c := range format.columns() {
+		header[i] = string(c)
+	}
+	cw.Write(header)
+
+	for _, f := range files {
+		cw.Write(format.row(f))
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteTable writes the files as an aligned table without a header, similar to 'ls -l'.
+func (files Files) WriteTable(w io.Writer, format Format) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, f := range files {
+		fmt.Fprintln(tw, strings.Join(format.row(f), "\t"))
+	}
+	return tw.Flush()
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// HumanSize renders a number of bytes using binary units, similar to 'ls -h', e.g. "1.5K".
+func HumanSize(n int64) string {
+	const units = "KMGTPE"
+	if n < 1024 {
+		return strconv.FormatInt(n, 10)
+	}
+
+	// round first, so that the unit and precision suit the rounded value
+	f := float64(n)
+	for i := 0; ; i++ {
+		f /= 1024
+		if r := math.Round(f*10) / 10; r < 10 {
+			return fmt.Sprintf("%.1f%c", r, units[i])
+		}
+		if r := math.Round(f); r < 1024 || i == len(units)-1 {
+			return fmt.Sprintf("%.0f%c", r, units[i])
+		}
+	}
+}
+
+// HumanAge renders a duration as an approximate age, e.g. "5m ago".
+func HumanAge(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return "just now"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d/time.Second))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d/time.Minute))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d/time.Hour))
+	}
+	return fmt.Sprintf("%dd ago", int(d/(24*time.Hour)))
+}
@@ -0,0 +1,142 @@
+package filemod
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	. "github.com/onsi/gomega"
+	"os"
+	"testing"
+	"time"
+)
+
+func encodeFixture() Files {
+	t0 := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	a := fileInfo{name: "a", size: 1536, mode: 0644, modTime: t0}
+	d := fileInfo{name: "d", size: 96, mode: os.ModeDir | 0755, modTime: t0.Add(-time.Hour), isDir: true}
+	x := fileInfo{err: os.ErrNotExist}
+	e := fileInfo{err: &os.PathError{Op: "stat", Path: "/e", Err: errors.New("denied")}}
+	fs = &osStub{[]fileInfo{a, d, x, e}} // global
+	return New("/a", "/d", "/x", "/e")
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	files := encodeFixture()
+
+	// When...
+	b, err := json.Marshal(files)
+	g.Expect(err).NotTo(HaveOccurred())
+	var decoded Files
+	err = json.Unmarshal(b, &decoded)
+
+	// Then...
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(b)).To(Equal(`[` +
+		`{"path":"/a","exists":true,"name":"a","size":1536,"mode":420,"permissions":"-rw-r--r--","modTime":"2020-01-01T12:00:00Z"},` +
+		`{"path":"/d","exists":true,"name":"d","size":96,"mode":2147484141,"permissions":"drwxr-xr-x","modTime":"2020-01-01T11:00:00Z","isDir":true},` +
+		`{"path":"/x","exists":false},` +
+		`{"path":"/e","exists":false,"error":{"op":"stat","message":"denied"}}]`))
+
+	g.Expect(decoded).To(HaveLen(4))
+	for i, f := range decoded {
+		g.Expect(f.Path()).To(Equal(files[i].Path()))
+		g.Expect(f.Exists()).To(Equal(files[i].Exists()))
+		g.Expect(f.Name()).To(Equal(files[i].Name()))
+		g.Expect(f.Size()).To(Equal(files[i].Size()))
+		g.Expect(f.Mode()).To(Equal(files[i].Mode()))
+		g.Expect(f.IsDir()).To(Equal(files[i].IsDir()))
+		g.Expect(f.ModTime().Equal(files[i].ModTime())).To(BeTrue())
+	}
+	g.Expect(decoded[3].Err()).To(MatchError("stat /e: denied"))
+	g.Expect(decoded[3].Err()).To(BeAssignableToTypeOf(&os.PathError{}))
+}
+
+func TestWriteJSONLines(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	files := encodeFixture()[2:]
+	buf := &bytes.Buffer{}
+
+	// When...
+	err := files.WriteJSONLines(buf)
+
+	// Then...
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(buf.String()).To(Equal(
+		`{"path":"/x","exists":false}` + "\n" +
+			`{"path":"/e","exists":false,"error":{"op":"stat","message":"denied"}}` + "\n"))
+}
+
+func TestWriteCSV(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	files := encodeFixture()
+	buf := &bytes.Buffer{}
+	columns, err := ParseColumns("path, size,error")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// When...
+	err = files.WriteCSV(buf, Format{Columns: columns, HumanSizes: true})
+
+	// Then...
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(buf.String()).To(Equal(
+		"path,size,error\n" +
+			"/a,1.5K,\n" +
+			"/d,96,\n" +
+			"/x,,\n" +
+			"/e,,stat /e: denied\n"))
+}
+
+func TestWriteTable(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	files := encodeFixture()
+	buf := &bytes.Buffer{}
+	Now = func() time.Time { return time.Date(2020, 1, 1, 12, 5, 0, 0, time.UTC) }
+	defer func() { Now = time.Now }()
+
+	// When...
+	err := files.WriteTable(buf, Format{})
+	err2 := files[:2].WriteTable(buf, Format{Columns: []Column{NameColumn, ModTimeColumn}, RelativeTimes: true})
+
+	// Then...
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(err2).NotTo(HaveOccurred())
+	g.Expect(buf.String()).To(Equal(
+		"-rw-r--r--  1536  2020-01-01T12:00:00Z  /a\n" +
+			"drwxr-xr-x  96    2020-01-01T11:00:00Z  /d\n" +
+			"absent                                  /x\n" +
+			"absent                                  /e\n" +
+			"a  5m ago\n" +
+			"d  1h ago\n"))
+}
+
+func TestParseColumnsError(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	_, err := ParseColumns("path,colour")
+
+	g.Expect(err).To(MatchError(`unknown column "colour"`))
+}
+
+func TestHumanSizeAndAge(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(HumanSize(0)).To(Equal("0"))
+	g.Expect(HumanSize(1023)).To(Equal("1023"))
+	g.Expect(HumanSize(1024)).To(Equal("1.0K"))
+	g.Expect(HumanSize(20 * 1024 * 1024)).To(Equal("20M"))
+	g.Expect(HumanSize(3 << 40)).To(Equal("3.0T"))
+	g.Expect(HumanSize(10188)).To(Equal("9.9K"))
+	g.Expect(HumanSize(10230)).To(Equal("10K"))
+	g.Expect(HumanSize(1048575)).To(Equal("1.0M"))
+	g.Expect(HumanSize(1<<63 - 1)).To(Equal("8.0E"))
+
+	g.Expect(HumanAge(0)).To(Equal("just now"))
+	g.Expect(HumanAge(59 * time.Second)).To(Equal("59s ago"))
+	g.Expect(HumanAge(47 * time.Hour)).To(Equal("47h ago"))
+	g.Expect(HumanAge(72 * time.Hour)).To(Equal("3d ago"))
+}
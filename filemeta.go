@@ -1,6 +1,7 @@
 package filemod
 
 import (
+	"io"
 	"os"
 	"time"
 )
@@ -10,6 +11,7 @@ type FileMetaInfo struct {
 	path string
 	err  error
 	fi   os.FileInfo // absent if file does not exist
+	fs   Statter     // absent means the default
 }
 
 // Tests whether the file exists.
@@ -74,51 +76,78 @@ func (file FileMetaInfo) Err() error {
 
 // Stat tests a file path using the operating system.
 func Stat(path string) FileMetaInfo {
+	return StatWith(fs, path)
+}
+
+// StatWith tests a file path using a particular Statter.
+func StatWith(s Statter, path string) FileMetaInfo {
 	Debug("stat %q\n", path)
 	if path == "" {
-		return FileMetaInfo{path: path}
+		return FileMetaInfo{path: path, fs: s}
 	}
 
-	info, err := fs.Stat(path)
+	info, err := s.Stat(path)
 
-	return newFileMetaInfo(path, err, info)
+	return newFileMetaInfo(s, path, err, info)
 }
 
 // Lstat tests a file path using the operating system.
 // If the file is a symbolic link, the returned FileInfo
 // describes the symbolic link. Lstat makes no attempt to follow the link.
 func Lstat(path string) FileMetaInfo {
+	return LstatWith(fs, path)
+}
+
+// LstatWith tests a file path using a particular Statter, without following
+// symbolic links.
+func LstatWith(s Statter, path string) FileMetaInfo {
 	Debug("lstat %q\n", path)
 	if path == "" {
-		return FileMetaInfo{path: path}
+		return FileMetaInfo{path: path, fs: s}
 	}
 
-	info, err := fs.Lstat(path)
+	info, err := s.Lstat(path)
 
-	return newFileMetaInfo(path, err, info)
+	return newFileMetaInfo(s, path, err, info)
 }
 
-func newFileMetaInfo(path string, err error, info os.FileInfo) FileMetaInfo {
+func newFileMetaInfo(s Statter, path string, err error, info os.FileInfo) FileMetaInfo {
 	if err != nil {
 		if os.IsNotExist(err) {
 			Debug("%q does not exist.\n", path)
-			return FileMetaInfo{path: path}
+			return FileMetaInfo{path: path, fs: s}
 		} else {
 			Debug("%q stat error %v.\n", path, err)
-			return FileMetaInfo{path: path, err: err}
+			return FileMetaInfo{path: path, err: err, fs: s}
 		}
 	}
 
 	return FileMetaInfo{
 		path: path,
 		fi:   info,
+		fs:   s,
 	}
 }
 
 // Refresh queries the operating system for the status of the file again.
 // A new FileMetaInfo is returned that contains the current status of the file.
 func (file FileMetaInfo) Refresh() FileMetaInfo {
-	return Stat(file.path)
+	return StatWith(file.statter(), file.path)
+}
+
+func (file FileMetaInfo) statter() Statter {
+	if file.fs == nil {
+		return fs
+	}
+	return file.fs
+}
+
+// Open opens the file for reading, using the Statter that provided its information.
+func (file FileMetaInfo) Open() (io.ReadCloser, error) {
+	if o, ok := file.statter().(Opener); ok {
+		return o.Open(file.path)
+	}
+	return nil, &os.PathError{Op: "open", Path: file.path, Err: errNotSupported}
 }
 
 //-------------------------------------------------------------------------------------------------
@@ -156,5 +185,5 @@ func (file FileMetaInfo) OlderThan(other FileMetaInfo) bool {
 //-------------------------------------------------------------------------------------------------
 
 // Debug is a function that prints trace information. By default it does nothing;
-// set it to (e.g.) 'fmt.Printf' to enable messages.
+// set it to (e.g.) 'fmt.Printf' to enable messages. For structured logging, use WithLogger.
 var Debug = func(message string, args ...interface{}) {}
@@ -238,4 +238,4 @@ func (stub *osStub) fakeStat() (os.FileInfo, error) {
 	return v, v.err
 }
 
-var _ statter = &osStub{}
+var _ Statter = &osStub{}
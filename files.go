@@ -12,10 +12,15 @@ type Files []FileMetaInfo
 // New builds file information for one or more files. If filesystem errors
 // arise, these are held in the files returned and can be inspected later.
 func New(paths ...string) Files {
+	return NewWith(fs, paths...)
+}
+
+// NewWith builds file information for one or more files using a particular Statter.
+func NewWith(s Statter, paths ...string) Files {
 	result := make(Files, len(paths))
 
 	for i, p := range paths {
-		fm := Stat(p)
+		fm := StatWith(s, p)
 		result[i] = fm
 	}
 
@@ -0,0 +1,255 @@
+package filemod
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Ignorer decides which files are ignored in a git working tree, according to the
+// patterns in .git/info/exclude, the top-level .gitignore and the .gitignore files in
+// subdirectories. The pattern syntax supported is: comments, negation with '!',
+// directory-only patterns with a trailing '/', anchored patterns containing '/', and
+// the wildcards '*', '?', '[...]', '[!...]' and '**'. POSIX classes such as
+// '[[:alpha:]]' are not supported.
+//
+// As with git, a file cannot be re-included if one of its parent directories is
+// ignored, and the .git directory is always ignored. The user's global excludes file
+// is not read.
+//
+// Ignore files are read when they are first needed. An Ignorer is safe for concurrent use.
+type Ignorer struct {
+	s    Statter
+	root string
+
+	mu       sync.Mutex
+	patterns map[string][]ignorePattern // keyed by slash-separated path relative to root
+	errs     Errors
+}
+
+type ignorePattern struct {
+	base     []string // the directory containing the ignore file
+	segments []string
+	negate   bool
+	dirOnly  bool
+}
+
+// NewIgnorer creates an Ignorer for the working tree at root.
+func NewIgnorer(root string) *Ignorer {
+	return NewIgnorerWith(fs, root)
+}
+
+// NewIgnorerWith creates an Ignorer for the working tree at root, using a particular
+// Statter, which must also be an Opener so that the ignore files can be read.
+func NewIgnorerWith(s Statter, root string) *Ignorer {
+	return &Ignorer{
+		s:        s,
+		root:     filepath.Clean(root),
+		patterns: make(map[string][]ignorePattern),
+	}
+}
+
+// Ignored returns true if a file is ignored. Files that are not beneath the root are
+// never ignored. Ignored can be used as Walker.Skip.
+func (ig *Ignorer) Ignored(file FileMetaInfo) bool {
+	rel, err := filepath.Rel(ig.root, filepath.Clean(file.path))
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+
+	segs := strings.Split(filepath.ToSlash(rel), "/")
+	for i := range segs {
+		if segs[i] == ".git" {
+			return true
+		}
+		isDir := i < len(segs)-1 || (file.Exists() && file.IsDir())
+		if ig.match(segs[:i+1], isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// NotIgnored returns true if a file is not ignored. It can be used with Files.Filter.
+func (ig *Ignorer) NotIgnored(file FileMetaInfo) bool {
+	return !ig.Ignored(file)
+}
+
+// Err returns any errors from reading the ignore files so far, as Errors, or nil.
+// Ignore files that do not exist are not errors.
+func (ig *Ignorer) Err() error {
+	ig.mu.Lock()
+	defer ig.mu.Unlock()
+	if len(ig.errs) > 0 {
+		return ig.errs
+	}
+	return nil
+}
+
+// match applies the patterns from the ignore files of every parent directory,
+// shallowest first, so that the last matching pattern decides.
+func (ig *Ignorer) match(segs []string, isDir bool) bool {
+	ignored := false
+	for _, p := range ig.applicable(segs[:len(segs)-1]) {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		if matchSegments(p.segments, segs[len(p.base):]) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+func (ig *Ignorer) applicable(dir []string) []ignorePattern {
+	ig.mu.Lock()
+	defer ig.mu.Unlock()
+
+	result := ig.load(nil, ".git/info/exclude")
+	for i := 0; i <= len(dir); i++ {
+		result = append(result, ig.load(dir[:i], path.Join(path.Join(dir[:i]...), ".gitignore"))...)
+	}
+	return result
+}
+
+func (ig *Ignorer) load(base []string, rel string) []ignorePattern {
+	if ps, exists := ig.patterns[rel]; exists {
+		return ps
+	}
+
+	ps, err := readIgnoreFile(ig.s, base, filepath.Join(ig.root, filepath.FromSlash(rel)))
+	if err != nil {
+		ig.errs = append(ig.errs, err)
+	}
+	ig.patterns[rel] = ps
+	return ps
+}
+
+func readIgnoreFile(s Statter, base []string, name string) ([]ignorePattern, error) {
+	o, ok := s.(Opener)
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: errNotSupported}
+	}
+
+	Debug("read %q\n", name)
+	f, err := o.Open(name)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) || !StatWith(s, filepath.Dir(name)).IsDir() {
+			return nil, nil // e.g. when .git is a file, as in linked worktrees
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var result []ignorePattern
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if p, ok := parseIgnorePattern(base, scanner.Text()); ok {
+			result = append(result, p)
+		}
+	}
+	return result, scanner.Err()
+}
+
+func parseIgnorePattern(base []string, line string) (ignorePattern, bool) {
+	p := ignorePattern{base: base}
+
+	line = strings.TrimSuffix(line, "\r")
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+
+	if line == "" || line[0] == '#' {
+		return p, false
+	}
+
+	switch {
+	case line[0] == '!':
+		p.negate = true
+		line = line[1:]
+	case strings.HasPrefix(line, `\!`), strings.HasPrefix(line, `\#`):
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimSuffix(line, "/")
+	}
+
+	if line == "" {
+		return p, false
+	}
+
+	line = negateClasses(line)
+	if strings.Contains(line, "/") {
+		// anchored to the directory containing the ignore file
+		p.segments = strings.Split(strings.TrimPrefix(line, "/"), "/")
+	} else {
+		// matches at any depth
+		p.segments = []string{"**", line}
+	}
+	return p, true
+}
+
+// negateClasses rewrites character classes into the form that path.Match understands:
+// a class negated with '!', as git allows, is negated with '^' instead, and a ']' first
+// in a class, which git takes literally, is escaped.
+func negateClasses(pattern string) string {
+	buf := &strings.Builder{}
+	inClass := false
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		buf.WriteByte(c)
+		switch {
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			buf.WriteByte(pattern[i])
+		case c == ']':
+			inClass = false
+		case c == '[' && !inClass:
+			inClass = true
+			if i+1 < len(pattern) && pattern[i+1] == '!' {
+				buf.WriteByte('^')
+				i++
+			}
+			if i+1 < len(pattern) && pattern[i+1] == ']' {
+				buf.WriteString(`\]`)
+				i++
+			}
+		}
+	}
+	return buf.String()
+}
+
+// matchSegments matches a slash-separated path against a pattern, one segment at a time.
+// The segment "**" matches zero or more segments, except at the end of the pattern,
+// where it matches one or more.
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			pattern = pattern[1:]
+			if len(pattern) == 0 {
+				return len(name) > 0
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
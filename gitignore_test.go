@@ -0,0 +1,138 @@
+package filemod
+
+import (
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ignoreTree creates a git working tree with a variety of ignore files.
+func ignoreTree(g *WithT) string {
+	dir := tempTree(g,
+		".git/HEAD", "a.go", "a.go.swp", "x.log", "keep.log",
+		"build/out", "build/keep.log", "src/build/out",
+		"docs/readme", "docs/x.tmp", "docs/a/b/x.tmp",
+		"lib/cache", "src/cache/y", "src/gen/z",
+		"src/important.log", "src/other.log", "src/local.txt", "src/sub/local.txt")
+
+	ignoreFiles := map[string]string{
+		".git/info/exclude": "*.swp\n",
+		".gitignore": "# build output\n" +
+			"/build/\n" +
+			"*.log\n" +
+			"!keep.log\n" +
+			"docs/**/*.tmp\n" +
+			"cache/  \n",
+		"src/.gitignore": "gen/\n!important.log\n/local.txt\n",
+	}
+	for name, content := range ignoreFiles {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		g.Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		g.Expect(ioutil.WriteFile(path, []byte(content), 0644)).To(Succeed())
+	}
+	return dir
+}
+
+func relPaths(dir string, files Files) []string {
+	result := make([]string, len(files))
+	for i, f := range files {
+		result[i] = filepath.ToSlash(strings.TrimPrefix(f.Path(), dir+string(filepath.Separator)))
+	}
+	return result
+}
+
+func TestWalkIgnoring(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	dir := ignoreTree(g)
+	defer os.RemoveAll(dir)
+	ig := NewIgnorer(dir)
+
+	// When...
+	files, err := Walker{Skip: ig.Ignored}.Walk(dir)
+
+	// Then...
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ig.Err()).NotTo(HaveOccurred())
+	g.Expect(relPaths(dir, files[1:])).To(Equal([]string{
+		".gitignore",
+		"a.go",
+		"docs", "docs/a", "docs/a/b", "docs/readme",
+		"keep.log",
+		"lib", "lib/cache",
+		"src", "src/.gitignore", "src/build", "src/build/out", "src/important.log",
+		"src/sub", "src/sub/local.txt",
+	}))
+}
+
+func TestIgnorerAsFilter(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	dir := ignoreTree(g)
+	defer os.RemoveAll(dir)
+	files := New(
+		filepath.Join(dir, "build", "keep.log"), // parent directory is ignored
+		filepath.Join(dir, "src", "important.log"),
+		filepath.Join(dir, "src", "gen"),
+		filepath.Join(dir, "docs", "new.tmp"), // does not exist
+		filepath.Join(dir, "docs", "new.txt"),
+		filepath.Join(dir, "..", "elsewhere.log"), // outside the tree
+	)
+
+	// When...
+	kept := files.Filter(NewIgnorer(dir).NotIgnored)
+
+	// Then...
+	g.Expect(relPaths(dir, kept)).To(Equal([]string{"src/important.log", "docs/new.txt", filepath.ToSlash(filepath.Join(dir, "..", "elsewhere.log"))}))
+}
+
+func TestIgnorePatterns(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cases := []struct {
+		pattern, path string
+		isDir, match  bool
+	}{
+		{"*.o", "a/b/c.o", false, true},
+		{"/*.o", "a/c.o", false, false},
+		{"/*.o", "c.o", false, true},
+		{"a/*.o", "a/c.o", false, true},
+		{"a/*.o", "a/b/c.o", false, false},
+		{"**/foo", "x/y/foo", false, true},
+		{"a/**/b", "a/b", false, true},
+		{"a/**/b", "a/x/y/b", false, true},
+		{"a/**", "a", true, false},
+		{"a/**", "a/x", false, true},
+		{"dir/", "x/dir", true, true},
+		{"dir/", "x/dir", false, false},
+		{`\#hash`, "#hash", false, true},
+		{`\!bang`, "!bang", false, true},
+		{"sp\\ ", "sp ", false, true},
+		{"f?[0-9]", "fx7", false, true},
+		{"[!a]*.log", "b.log", false, true},
+		{"[!a]*.log", "a.log", false, false},
+		{`\[!a]`, "[!a]", false, true},
+		{"[!]]x", "ax", false, true},
+		{"[!]]x", "]x", false, false},
+		{"[a[!]", "!", false, true},
+	}
+
+	for _, c := range cases {
+		p, ok := parseIgnorePattern(nil, c.pattern)
+		g.Expect(ok).To(BeTrue(), c.pattern)
+		m := matchSegments(p.segments, strings.Split(c.path, "/")) && (c.isDir || !p.dirOnly)
+		g.Expect(m).To(Equal(c.match), c.pattern+" "+c.path)
+	}
+
+	_, ok := parseIgnorePattern(nil, "# comment")
+	g.Expect(ok).To(BeFalse())
+	_, ok = parseIgnorePattern(nil, "   ")
+	g.Expect(ok).To(BeFalse())
+	p, _ := parseIgnorePattern(nil, "!x")
+	g.Expect(p.negate).To(BeTrue())
+}
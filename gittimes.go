@@ -0,0 +1,369 @@
+package filemod
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GitTimeSource selects where WithGitTimes finds the timestamps of files.
+type GitTimeSource int
+
+const (
+	// LastCommitTime is the committer time of the most recent commit that touched each
+	// file, as shown by 'git log'. For files with local changes, i.e. that differ from
+	// HEAD, the filesystem time is used if it is later. This requires the git command,
+	// but no network access.
+	LastCommitTime GitTimeSource = iota
+	// IndexTime is the modification time cached in the git index, i.e. when each file
+	// was last staged. The index is read directly.
+	IndexTime
+)
+
+func (src GitTimeSource) String() string {
+	switch src {
+	case LastCommitTime:
+		return "last commit"
+	case IndexTime:
+		return "index"
+	}
+	return fmt.Sprintf("GitTimeSource(%d)", int(src))
+}
+
+// WithGitTimes wraps a Statter so that the modification times of the files tracked in a
+// git repository are taken from the repository instead of the filesystem. This is useful
+// after a fresh clone, when every file has the same checkout time.
+//
+// The root is the top of the working tree, i.e. the directory containing .git. The time
+// of each directory is that of the newest tracked file beneath it. Untracked files keep
+// their own modification times. The timestamps are read once, when WithGitTimes is called,
+// as is the list of files with local changes; the times of such files are rechecked each
+// time they are tested.
+//
+// The wrapper is also a DirReader, an Opener, a Remover, a TimeChanger and a ModeChanger,
+// delegating to the wrapped Statter where it supports these. Setting the time of a
+// tracked file has no visible effect, because its time comes from the repository.
+func WithGitTimes(s Statter, root string, source GitTimeSource) (Statter, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var times map[string]time.Time
+	var changed map[string]bool
+	switch source {
+	case LastCommitTime:
+		times, err = gitCommitTimes(abs)
+		if err == nil {
+			changed, err = gitChanged(abs)
+		}
+	case IndexTime:
+		times, err = gitIndexTimes(s, abs)
+	default:
+		err = fmt.Errorf("unknown %v", source)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for p := range changed {
+		if fi, err := s.Lstat(filepath.Join(abs, filepath.FromSlash(p))); err == nil && fi.ModTime().After(times[p]) {
+			times[p] = fi.ModTime()
+		}
+	}
+
+	for p, t := range times {
+		for dir := path.Dir(p); ; dir = path.Dir(dir) {
+			if t.After(times[dir]) {
+				times[dir] = t
+			}
+			if dir == "." {
+				break
+			}
+		}
+	}
+
+	return gitTimes{s: s, root: abs, times: times, changed: changed}, nil
+}
+
+type gitTimes struct {
+	s       Statter
+	root    string
+	times   map[string]time.Time // keyed by slash-separated path relative to root
+	changed map[string]bool      // files with local changes, which may be newer
+}
+
+func (g gitTimes) Stat(name string) (os.FileInfo, error) {
+	fi, err := g.s.Stat(name)
+	return g.info(name, fi), err
+}
+
+func (g gitTimes) Lstat(name string) (os.FileInfo, error) {
+	fi, err := g.s.Lstat(name)
+	return g.info(name, fi), err
+}
+
+func (g gitTimes) ReadDir(name string) ([]os.FileInfo, error) {
+	list, err := readDir(g.s, name)
+	for i, fi := range list {
+		list[i] = g.info(filepath.Join(name, fi.Name()), fi)
+	}
+	return list, err
+}
+
+func (g gitTimes) Open(name string) (io.ReadCloser, error) {
+	o, ok := g.s.(Opener)
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: errNotSupported}
+	}
+	return o.Open(name)
+}
+
+func (g gitTimes) Remove(name string) error {
+	return remove(g.s, name)
+}
+
+func (g gitTimes) RemoveAll(name string) error {
+	return removeAll(g.s, name)
+}
+
+func (g gitTimes) Chtimes(name string, mtime time.Time) error {
+	return chtimes(g.s, name, mtime)
+}
+
+func (g gitTimes) Chmod(name string, mode os.FileMode) error {
+	return chmod(g.s, name, mode)
+}
+
+func (g gitTimes) info(name string, fi os.FileInfo) os.FileInfo {
+	if fi == nil {
+		return nil
+	}
+
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return fi
+	}
+	rel, err := filepath.Rel(g.root, abs)
+	if err != nil {
+		return fi
+	}
+
+	rel = filepath.ToSlash(rel)
+	if t, exists := g.times[rel]; exists {
+		if g.changed[rel] && fi.ModTime().After(t) {
+			return fi
+		}
+		return gitInfo{FileInfo: fi, modTime: t}
+	}
+	return fi
+}
+
+// gitInfo overrides the modification time of a file.
+type gitInfo struct {
+	os.FileInfo
+	modTime time.Time
+}
+
+func (fi gitInfo) ModTime() time.Time {
+	return fi.modTime
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// commitMarker starts each commit in the 'git log' output; it cannot begin a file name
+// in practice.
+const commitMarker = "\x01commit "
+
+func gitCommitTimes(root string) (map[string]time.Time, error) {
+	Debug("git log in %q\n", root)
+	cmd := exec.Command("git", "-C", root, "log", "--no-renames", "--name-only", "-z", "--format=tformat:%x01commit %ct")
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log in %s: %w: %s", root, err, strings.TrimSpace(stderr.String()))
+	}
+
+	// the output is newest first, so the first time seen for each file is the one wanted
+	times := make(map[string]time.Time)
+	var current time.Time
+	for _, token := range strings.Split(string(out), "\x00") {
+		token = strings.TrimPrefix(token, "\n")
+		if strings.HasPrefix(token, commitMarker) {
+			secs, err := strconv.ParseInt(strings.TrimPrefix(token, commitMarker), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("git log in %s: %w", root, err)
+			}
+			current = time.Unix(secs, 0)
+		} else if _, exists := times[token]; token != "" && !exists {
+			times[token] = current
+		}
+	}
+	return times, nil
+}
+
+// gitChanged lists the tracked files that differ from HEAD, whether staged or not.
+func gitChanged(root string) (map[string]bool, error) {
+	Debug("git diff in %q\n", root)
+	cmd := exec.Command("git", "-C", root, "diff", "--no-renames", "--name-only", "-z", "HEAD")
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff in %s: %w: %s", root, err, strings.TrimSpace(stderr.String()))
+	}
+
+	changed := make(map[string]bool)
+	for _, name := range strings.Split(string(out), "\x00") {
+		if name != "" {
+			changed[name] = true
+		}
+	}
+	return changed, nil
+}
+
+//-------------------------------------------------------------------------------------------------
+
+var errBadIndex = errors.New("unsupported or corrupt git index")
+
+// gitDir finds the git directory of a working tree. In linked worktrees and submodules,
+// .git is a file that refers to the real git directory.
+func gitDir(s Statter, root string) (string, error) {
+	dir := filepath.Join(root, ".git")
+	if StatWith(s, dir).IsDir() {
+		return dir, nil
+	}
+
+	content, err := readFile(s, dir)
+	if err != nil {
+		return "", err
+	}
+
+	target := strings.TrimSpace(strings.TrimPrefix(string(content), "gitdir:"))
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(root, target)
+	}
+	return target, nil
+}
+
+// gitIndexTimes reads the modification times cached in the git index, which may be
+// version 2, 3 or 4, using SHA-1 or SHA-256 object names.
+func gitIndexTimes(s Statter, root string) (map[string]time.Time, error) {
+	dir, err := gitDir(s, root)
+	if err != nil {
+		return nil, err
+	}
+
+	hashSize := 20
+	if config, err := readFile(s, filepath.Join(dir, "config")); err == nil {
+		for _, line := range strings.Split(string(config), "\n") {
+			if strings.Join(strings.Fields(strings.ToLower(line)), "") == "objectformat=sha256" {
+				hashSize = 32
+			}
+		}
+	}
+
+	name := filepath.Join(dir, "index")
+	Debug("read %q\n", name)
+	data, err := readFile(s, name)
+	if err != nil {
+		return nil, err
+	}
+
+	times, err := parseGitIndex(data, hashSize)
+	if err != nil {
+		return nil, &os.PathError{Op: "read", Path: name, Err: err}
+	}
+	return times, nil
+}
+
+func parseGitIndex(data []byte, hashSize int) (map[string]time.Time, error) {
+	if len(data) < 12 || string(data[:4]) != "DIRC" {
+		return nil, errBadIndex
+	}
+
+	version := binary.BigEndian.Uint32(data[4:])
+	if version < 2 || version > 4 {
+		return nil, errBadIndex
+	}
+
+	count := binary.BigEndian.Uint32(data[8:])
+	times := make(map[string]time.Time, count)
+	fixed := 40 + hashSize + 2 // stat data, object name, flags
+	previous := ""
+	pos := 12
+
+	for i := uint32(0); i < count; i++ {
+		if pos+fixed > len(data) {
+			return nil, errBadIndex
+		}
+		entry := data[pos:]
+		mtime := time.Unix(int64(binary.BigEndian.Uint32(entry[8:])), int64(binary.BigEndian.Uint32(entry[12:])))
+		flags := binary.BigEndian.Uint16(entry[40+hashSize:])
+
+		n := fixed
+		if flags&0x4000 != 0 && version >= 3 {
+			n += 2 // extended flags
+		}
+		if n > len(entry) {
+			return nil, errBadIndex
+		}
+
+		var name string
+		if version == 4 {
+			// the name is the previous name, less some trailing bytes, plus a new suffix
+			strip, width := indexVarint(entry[n:])
+			if width == 0 || strip > len(previous) {
+				return nil, errBadIndex
+			}
+			n += width
+			end := bytes.IndexByte(entry[n:], 0)
+			if end < 0 {
+				return nil, errBadIndex
+			}
+			name = previous[:len(previous)-strip] + string(entry[n:n+end])
+			pos += n + end + 1
+		} else {
+			end := bytes.IndexByte(entry[n:], 0)
+			if end < 0 {
+				return nil, errBadIndex
+			}
+			name = string(entry[n : n+end])
+			pos += (n + end + 8) &^ 7 // NUL padded to a multiple of eight bytes
+		}
+
+		if _, exists := times[name]; !exists {
+			times[name] = mtime
+		}
+		previous = name
+	}
+
+	return times, nil
+}
+
+// indexVarint decodes git's offset encoding, returning the value and the number of bytes used.
+func indexVarint(b []byte) (int, int) {
+	if len(b) == 0 {
+		return 0, 0
+	}
+	val := int(b[0] & 0x7f)
+	i := 1
+	for b[i-1]&0x80 != 0 {
+		if i >= len(b) {
+			return 0, 0
+		}
+		val = ((val + 1) << 7) | int(b[i]&0x7f)
+		i++
+	}
+	return val, i
+}
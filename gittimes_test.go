@@ -0,0 +1,157 @@
+package filemod
+
+import (
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func git(g *WithT, dir string, when time.Time, args ...string) {
+	cmd := exec.Command("git", append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_DATE="+when.Format(time.RFC3339),
+		"GIT_COMMITTER_DATE="+when.Format(time.RFC3339))
+	out, err := cmd.CombinedOutput()
+	g.Expect(err).NotTo(HaveOccurred(), string(out))
+}
+
+func gitTree(t *testing.T, g *WithT) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+	fs = osFacade{} // the real deal
+	dir := tempTree(g, "a", "d/b", "d/c", "untracked")
+	git(g, dir, time.Now(), "init", "-q")
+	return dir
+}
+
+func TestGitCommitTimes(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	dir := gitTree(t, g)
+	defer os.RemoveAll(dir)
+	t1 := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	checkout := t1.Add(24 * time.Hour)
+
+	git(g, dir, t1, "add", "a", "d/b")
+	git(g, dir, t1, "commit", "-q", "-m", "first")
+	git(g, dir, t2, "add", "d/c")
+	git(g, dir, t2, "commit", "-q", "-m", "second")
+	for _, name := range []string{"a", "d/b", "d/c", "untracked"} {
+		g.Expect(os.Chtimes(filepath.Join(dir, name), checkout, checkout)).To(Succeed())
+	}
+
+	// When...
+	gs, err := WithGitTimes(OS, dir, LastCommitTime)
+	g.Expect(err).NotTo(HaveOccurred())
+	files := NewWith(gs,
+		filepath.Join(dir, "a"),
+		filepath.Join(dir, "d", "b"),
+		filepath.Join(dir, "d", "c"),
+		filepath.Join(dir, "d"),
+		filepath.Join(dir, "untracked"))
+	walked, err := WalkWith(gs, dir)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// Then...
+	g.Expect(files[0].ModTime()).To(BeTemporally("==", t1))
+	g.Expect(files[1].ModTime()).To(BeTemporally("==", t1))
+	g.Expect(files[2].ModTime()).To(BeTemporally("==", t2))
+	g.Expect(files[3].ModTime()).To(BeTemporally("==", t2))
+	g.Expect(files[4].ModTime()).To(BeTemporally("==", checkout))
+	g.Expect(files[2:3].AllAreNewerThan(files[:2])).To(BeTrue())
+	g.Expect(walked[0].ModTime()).To(BeTemporally("==", t2))
+
+	// When...
+	deleted, err := files[4:].Prune(KeepNewest(0), false)
+
+	// Then...
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(deleted).To(HaveLen(1))
+	g.Expect(filepath.Join(dir, "untracked")).NotTo(BeAnExistingFile())
+}
+
+func TestGitCommitTimesLocalChanges(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	dir := gitTree(t, g)
+	defer os.RemoveAll(dir)
+	t1 := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	edited := t1.Add(48 * time.Hour)
+
+	git(g, dir, t1, "add", "a", "d/b")
+	git(g, dir, t1, "commit", "-q", "-m", "first")
+	g.Expect(ioutil.WriteFile(filepath.Join(dir, "d", "b"), []byte("edited"), 0644)).To(Succeed())
+	g.Expect(os.Chtimes(filepath.Join(dir, "d", "b"), edited, edited)).To(Succeed())
+	g.Expect(os.Chtimes(filepath.Join(dir, "a"), edited, edited)).To(Succeed())
+
+	// When...
+	gs, err := WithGitTimes(OS, dir, LastCommitTime)
+	g.Expect(err).NotTo(HaveOccurred())
+	files := NewWith(gs, filepath.Join(dir, "a"), filepath.Join(dir, "d", "b"), filepath.Join(dir, "d"))
+
+	// Then...
+	g.Expect(files[0].ModTime()).To(BeTemporally("==", t1))
+	g.Expect(files[1].ModTime()).To(BeTemporally("==", edited))
+	g.Expect(files[2].ModTime()).To(BeTemporally("==", edited))
+
+	// When...
+	later := edited.Add(time.Hour)
+	g.Expect(os.Chtimes(filepath.Join(dir, "d", "b"), later, later)).To(Succeed())
+
+	// Then...
+	g.Expect(files[1].Refresh().ModTime()).To(BeTemporally("==", later))
+}
+
+func TestGitIndexTimes(t *testing.T) {
+	for _, version := range []string{"2", "4"} {
+		g := NewGomegaWithT(t)
+		// Given...
+		dir := gitTree(t, g)
+		defer os.RemoveAll(dir)
+		t1 := time.Date(2020, 1, 1, 12, 0, 0, 123456789, time.UTC)
+		later := t1.Add(24 * time.Hour)
+
+		for _, name := range []string{"a", "d/b", "d/c"} {
+			g.Expect(os.Chtimes(filepath.Join(dir, name), t1, t1)).To(Succeed())
+			t1 = t1.Add(time.Minute)
+		}
+		git(g, dir, t1, "add", "a", "d")
+		git(g, dir, t1, "update-index", "--index-version", version)
+		for _, name := range []string{"a", "d/b", "d/c"} {
+			g.Expect(os.Chtimes(filepath.Join(dir, name), later, later)).To(Succeed())
+		}
+
+		// When...
+		gs, err := WithGitTimes(OS, dir, IndexTime)
+		g.Expect(err).NotTo(HaveOccurred())
+		files := NewWith(gs, filepath.Join(dir, "a"), filepath.Join(dir, "d", "c"), filepath.Join(dir, "d"), filepath.Join(dir, "untracked"))
+
+		// Then...
+		g.Expect(files[0].ModTime()).To(BeTemporally("==", t1.Add(-3*time.Minute)), version)
+		g.Expect(files[1].ModTime()).To(BeTemporally("==", t1.Add(-time.Minute)), version)
+		g.Expect(files[2].ModTime()).To(BeTemporally("==", t1.Add(-time.Minute)), version)
+		g.Expect(files[3].ModTime()).NotTo(BeTemporally("==", files[2].ModTime()), version)
+	}
+}
+
+func TestGitTimesErrors(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	dir := tempTree(g, "a")
+	defer os.RemoveAll(dir)
+
+	// When...
+	_, err := WithGitTimes(OS, dir, IndexTime)
+	_, err2 := parseGitIndex([]byte("DIRC\x00\x00\x00\x02\x00\x00\x00\x01"), 20)
+
+	// Then...
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err2).To(Equal(errBadIndex))
+}
@@ -1,9 +1,16 @@
 module github.com/rickb777/filemod
 
-go 1.15
+go 1.23
 
 require (
 	github.com/onsi/gomega v1.10.2
-	golang.org/x/net v0.0.0-20201006153459-a7d1128ccaa0 // indirect
+	golang.org/x/crypto v0.31.0
+)
+
+require (
+	golang.org/x/net v0.21.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/text v0.21.0 // indirect
 	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
+	gopkg.in/yaml.v2 v2.3.0 // indirect
 )
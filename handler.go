@@ -0,0 +1,145 @@
+package filemod
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ConditionalHandler wraps an http.Handler that serves content generated from a group
+// of files, such as a bundle, so that conditional GET requests are answered without
+// invoking it. The Last-Modified header is the newest modification time in the group
+// and the ETag is a strong entity tag computed from the fingerprints of the files (see
+// Fingerprint), so it changes whenever any of the files is changed, added or removed.
+//
+// Requests with a matching If-None-Match header, or failing that an If-Modified-Since
+// header that is not before the last modification, are answered with 304 Not Modified.
+//
+// The files are tested again whenever Refresh is called and, optionally, on a schedule.
+type ConditionalHandler struct {
+	next http.Handler
+	stop chan struct{}
+	once sync.Once
+
+	mu           sync.RWMutex
+	files        Files
+	lastModified time.Time
+	etag         string
+}
+
+// NewConditionalHandler creates a handler for a group of files, delegating to next for
+// requests that are not answered with 304 Not Modified. If interval is positive, the
+// files are refreshed periodically until Stop is called.
+func NewConditionalHandler(files Files, next http.Handler, interval time.Duration) *ConditionalHandler {
+	h := &ConditionalHandler{next: next, stop: make(chan struct{})}
+	h.update(files)
+
+	if interval > 0 {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					h.Refresh()
+				case <-h.stop:
+					return
+				}
+			}
+		}()
+	}
+	return h
+}
+
+// Refresh tests the files again, updating the Last-Modified time and the ETag.
+func (h *ConditionalHandler) Refresh() {
+	h.mu.RLock()
+	files := make(Files, len(h.files))
+	for i, f := range h.files {
+		files[i] = f.Refresh()
+	}
+	h.mu.RUnlock()
+
+	h.update(files)
+}
+
+func (h *ConditionalHandler) update(files Files) {
+	var newest time.Time
+	for _, f := range files {
+		if f.Exists() && f.ModTime().After(newest) {
+			newest = f.ModTime()
+		}
+	}
+
+	st, _ := NewStamp(files, false) // cannot fail without hashes
+	data, _ := json.Marshal(st)
+	sum := sha256.Sum256(data)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.files = files
+	h.lastModified = newest.UTC().Truncate(time.Second) // the precision of HTTP dates
+	h.etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// Stop ends the periodic refreshing, if any.
+func (h *ConditionalHandler) Stop() {
+	h.once.Do(func() { close(h.stop) })
+}
+
+// LastModified gets the newest modification time of the files, in whole seconds.
+func (h *ConditionalHandler) LastModified() time.Time {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.lastModified
+}
+
+// ETag gets the strong entity tag of the files, including its quotes.
+func (h *ConditionalHandler) ETag() string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.etag
+}
+
+// ServeHTTP answers conditional GET and HEAD requests with 304 Not Modified when possible.
+// Otherwise, the Last-Modified and ETag headers are set and the request is passed on.
+func (h *ConditionalHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	lastModified, etag := h.lastModified, h.etag
+	h.mu.RUnlock()
+
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+	}
+	w.Header().Set("ETag", etag)
+
+	if (r.Method == http.MethodGet || r.Method == http.MethodHead) && notModified(r, lastModified, etag) {
+		Debug("%s %s not modified\n", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	h.next.ServeHTTP(w, r)
+}
+
+func notModified(r *http.Request, lastModified time.Time, etag string) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/") // the weak comparison applies
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(ims)
+		return err == nil && !lastModified.After(t)
+	}
+	return false
+}
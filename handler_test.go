@@ -0,0 +1,92 @@
+package filemod
+
+import (
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConditionalHandler(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	dir := tempTree(g, "a.js", "b.js")
+	defer os.RemoveAll(dir)
+	t1 := time.Date(2020, 1, 1, 12, 0, 0, 500000000, time.UTC)
+	for _, name := range []string{"a.js", "b.js"} {
+		g.Expect(os.Chtimes(filepath.Join(dir, name), t1, t1)).To(Succeed())
+	}
+	calls := 0
+	bundle := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte("bundle"))
+	})
+	h := NewConditionalHandler(New(filepath.Join(dir, "a.js"), filepath.Join(dir, "b.js")), bundle, 0)
+	defer h.Stop()
+
+	get := func(header, value string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/bundle.js", nil)
+		if header != "" {
+			r.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	// When...
+	plain := get("", "")
+	etag := plain.Header().Get("ETag")
+	byETag := get("If-None-Match", `"other", W/`+etag)
+	byDate := get("If-Modified-Since", "Wed, 01 Jan 2020 12:00:00 GMT")
+	byOlderDate := get("If-Modified-Since", "Wed, 01 Jan 2020 11:59:59 GMT")
+	byOtherETag := get("If-None-Match", `"other"`)
+
+	// Then...
+	g.Expect(plain.Code).To(Equal(http.StatusOK))
+	g.Expect(plain.Body.String()).To(Equal("bundle"))
+	g.Expect(plain.Header().Get("Last-Modified")).To(Equal("Wed, 01 Jan 2020 12:00:00 GMT"))
+	g.Expect(etag).To(MatchRegexp(`^"[0-9a-f]{32}"$`))
+	g.Expect(byETag.Code).To(Equal(http.StatusNotModified))
+	g.Expect(byETag.Body.String()).To(BeEmpty())
+	g.Expect(byDate.Code).To(Equal(http.StatusNotModified))
+	g.Expect(byOlderDate.Code).To(Equal(http.StatusOK))
+	g.Expect(byOtherETag.Code).To(Equal(http.StatusOK))
+	g.Expect(calls).To(Equal(3))
+
+	// When...
+	t2 := t1.Add(time.Minute)
+	g.Expect(ioutil.WriteFile(filepath.Join(dir, "b.js"), []byte("changed"), 0644)).To(Succeed())
+	g.Expect(os.Chtimes(filepath.Join(dir, "b.js"), t2, t2)).To(Succeed())
+	h.Refresh()
+	afterChange := get("If-None-Match", etag)
+
+	// Then...
+	g.Expect(afterChange.Code).To(Equal(http.StatusOK))
+	g.Expect(afterChange.Header().Get("ETag")).NotTo(Equal(etag))
+	g.Expect(h.LastModified()).To(Equal(time.Date(2020, 1, 1, 12, 1, 0, 0, time.UTC)))
+}
+
+func TestConditionalHandlerScheduledRefresh(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	dir := tempTree(g, "a.js")
+	defer os.RemoveAll(dir)
+	h := NewConditionalHandler(New(filepath.Join(dir, "a.js"), filepath.Join(dir, "b.js")), http.NotFoundHandler(), 10*time.Millisecond)
+	defer h.Stop()
+	etag := h.ETag()
+
+	// When...
+	g.Expect(ioutil.WriteFile(filepath.Join(dir, "b.js"), []byte("new"), 0644)).To(Succeed())
+
+	// Then...
+	g.Eventually(h.ETag).ShouldNot(Equal(etag))
+	h.Stop()
+	h.Stop() // harmless
+}
@@ -0,0 +1,142 @@
+package filemod
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"time"
+)
+
+// HTTP is a Statter for resources on web servers, such as artefact repositories. The
+// paths are URLs, which are tested with HEAD requests. The Last-Modified and
+// Content-Length headers give the modification time and size; resources without a
+// Last-Modified header have a zero modification time. Responses of 404 Not Found and
+// 410 Gone mean that the resource does not exist.
+//
+// HTTP is also an Opener, using GET requests, so that content keys such as ContentHash
+// can be used. It is not a DirReader.
+//
+// For example, remote artefacts can be compared with local outputs:
+//
+//	remote := filemod.NewWith(filemod.NewHTTP(nil), "https://example.com/lib.jar")
+//	upToDate := filemod.New("build/app.jar").AllAreNewerThan(remote)
+type HTTP struct {
+	client *http.Client
+}
+
+// NewHTTP creates an HTTP Statter using a particular client, which controls timeouts,
+// redirects and so on. If the client is nil, http.DefaultClient is used.
+func NewHTTP(client *http.Client) *HTTP {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTP{client: client}
+}
+
+// Stat makes a HEAD request for a URL. Redirects are followed, as determined by the client.
+func (h *HTTP) Stat(name string) (os.FileInfo, error) {
+	Debug("head %q\n", name)
+	resp, err := h.client.Head(name)
+	if err != nil {
+		return nil, &os.PathError{Op: "head", Path: name, Err: err}
+	}
+	resp.Body.Close()
+
+	if err = checkStatus("head", name, resp); err != nil {
+		return nil, err
+	}
+
+	fi := httpInfo{name: urlBase(name), size: resp.ContentLength, header: resp.Header}
+	if fi.size < 0 {
+		fi.size = 0 // unknown
+	}
+	if lm := resp.Header.Get("Last-Modified"); lm != "" {
+		fi.modTime, _ = http.ParseTime(lm)
+	}
+	return fi, nil
+}
+
+// Lstat is the same as Stat.
+func (h *HTTP) Lstat(name string) (os.FileInfo, error) {
+	return h.Stat(name)
+}
+
+// Open makes a GET request for a URL, returning the body of the response.
+func (h *HTTP) Open(name string) (io.ReadCloser, error) {
+	Debug("get %q\n", name)
+	resp, err := h.client.Get(name)
+	if err != nil {
+		return nil, &os.PathError{Op: "get", Path: name, Err: err}
+	}
+
+	if err = checkStatus("get", name, resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func checkStatus(op, name string, resp *http.Response) error {
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return &os.PathError{Op: op, Path: name, Err: os.ErrNotExist}
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return &os.PathError{Op: op, Path: name, Err: fmt.Errorf("HTTP status %s", resp.Status)}
+	}
+	return nil
+}
+
+func urlBase(name string) string {
+	u, err := url.Parse(name)
+	if err != nil || u.Path == "" {
+		return name
+	}
+	return path.Base(u.Path)
+}
+
+// ETag gets the entity tag of a file obtained using HTTP, including its quotes and any
+// weak indicator. It is empty for other files, and when the server gave no ETag header.
+func ETag(file FileMetaInfo) string {
+	if h, ok := file.Sys().(http.Header); ok {
+		return h.Get("ETag")
+	}
+	return ""
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// httpInfo is the information for a resource obtained using HTTP. Sys gives the
+// response headers.
+type httpInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	header  http.Header
+}
+
+func (fi httpInfo) Name() string {
+	return fi.name
+}
+
+func (fi httpInfo) Size() int64 {
+	return fi.size
+}
+
+func (fi httpInfo) Mode() os.FileMode {
+	return 0444
+}
+
+func (fi httpInfo) ModTime() time.Time {
+	return fi.modTime
+}
+
+func (fi httpInfo) IsDir() bool {
+	return false
+}
+
+func (fi httpInfo) Sys() interface{} {
+	return fi.header
+}
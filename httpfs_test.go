@@ -0,0 +1,98 @@
+package filemod
+
+import (
+	"bytes"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var httpTime = time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func artefactServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/lib/a.jar":
+			w.Header().Set("ETag", `"v1"`)
+			http.ServeContent(w, r, "a.jar", httpTime, bytes.NewReader([]byte("content of a")))
+		case "/lib/b.jar":
+			w.Write([]byte("b")) // no Last-Modified
+		case "/gone":
+			w.WriteHeader(http.StatusGone)
+		case "/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestHTTPStat(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	server := artefactServer()
+	defer server.Close()
+	h := NewHTTP(server.Client())
+
+	// When...
+	files := NewWith(h,
+		server.URL+"/lib/a.jar",
+		server.URL+"/lib/b.jar",
+		server.URL+"/lib/missing.jar",
+		server.URL+"/gone",
+		server.URL+"/broken")
+
+	// Then...
+	a := files[0]
+	g.Expect(a.Exists()).To(BeTrue())
+	g.Expect(a.Name()).To(Equal("a.jar"))
+	g.Expect(a.Size()).To(BeEquivalentTo(12))
+	g.Expect(a.ModTime()).To(BeTemporally("==", httpTime))
+	g.Expect(ETag(a)).To(Equal(`"v1"`))
+
+	g.Expect(files[1].Exists()).To(BeTrue())
+	g.Expect(files[1].ModTime().IsZero()).To(BeTrue())
+	g.Expect(ETag(files[1])).To(BeEmpty())
+
+	g.Expect(files[2].Exists()).To(BeFalse())
+	g.Expect(files[2].Err()).NotTo(HaveOccurred())
+	g.Expect(files[3].Exists()).To(BeFalse())
+	g.Expect(files[3].Err()).NotTo(HaveOccurred())
+	g.Expect(files[4].Exists()).To(BeFalse())
+	g.Expect(files[4].Err()).To(MatchError(ContainSubstring("HTTP status 500")))
+
+	rc, err := a.Open()
+	g.Expect(err).NotTo(HaveOccurred())
+	content, _ := ioutil.ReadAll(rc)
+	rc.Close()
+	g.Expect(string(content)).To(Equal("content of a"))
+	_, err = files[2].Open()
+	g.Expect(os.IsNotExist(err)).To(BeTrue())
+}
+
+func TestHTTPComparedWithLocalFiles(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	server := artefactServer()
+	defer server.Close()
+	dir := tempTree(g, "app.jar")
+	defer os.RemoveAll(dir)
+	local := New(filepath.Join(dir, "app.jar"))
+	remote := NewWith(NewHTTP(server.Client()), server.URL+"/lib/a.jar")
+
+	// When...
+	newer := local.AllAreNewerThan(remote)
+	old := httpTime.Add(-time.Hour)
+	g.Expect(os.Chtimes(local[0].Path(), old, old)).To(Succeed())
+	stale := Files{local[0].Refresh()}.AllAreNewerThan(remote)
+
+	// Then...
+	g.Expect(newer).To(BeTrue())
+	g.Expect(stale).To(BeFalse())
+}
@@ -0,0 +1,20 @@
+//go:build windows || plan9
+
+package filemod
+
+import "os"
+
+// inodeOf gets the device and inode numbers of a file; these are not available on this platform.
+func inodeOf(fi os.FileInfo) (dev, ino uint64, ok bool) {
+	return 0, 0, false
+}
+
+// blocksOf gets the number of bytes allocated to a file; this is not available on this platform.
+func blocksOf(fi os.FileInfo) (int64, bool) {
+	return 0, false
+}
+
+// syncDir would flush a directory to disk; directories cannot be synced on this platform.
+func syncDir(dir string) error {
+	return nil
+}
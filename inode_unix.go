@@ -0,0 +1,37 @@
+//go:build !windows && !plan9
+
+package filemod
+
+import (
+	"os"
+	"syscall"
+)
+
+// inodeOf gets the device and inode numbers of a file, if the platform provides them.
+func inodeOf(fi os.FileInfo) (dev, ino uint64, ok bool) {
+	if st, isStat := fi.Sys().(*syscall.Stat_t); isStat && st != nil {
+		return uint64(st.Dev), uint64(st.Ino), true
+	}
+	return 0, 0, false
+}
+
+// blocksOf gets the number of bytes allocated to a file, if the platform provides it.
+func blocksOf(fi os.FileInfo) (int64, bool) {
+	if st, isStat := fi.Sys().(*syscall.Stat_t); isStat && st != nil {
+		return int64(st.Blocks) * 512, true
+	}
+	return 0, false
+}
+
+// syncDir flushes a directory to disk, so that a rename within it survives a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if cerr := d.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,112 @@
+package filemod
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// WithLogger wraps a Statter so that every operation it performs is logged as a structured
+// record carrying the operation, path, duration and error (if any). Successful operations,
+// and those finding that a file does not exist, are logged at debug level; other failures
+// are logged at warning level.
+//
+// This allows logging to be configured separately for each Statter in use, for example
+//
+//	files := filemod.NewWith(filemod.WithLogger(filemod.OS, logger), paths...)
+//
+// The wrapper is also a DirReader, an Opener, a Remover, a TimeChanger and a
+// ModeChanger, delegating to the wrapped Statter where it supports these. If the logger
+// is nil, slog.Default() is used.
+func WithLogger(s Statter, logger *slog.Logger) Statter {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return loggingStatter{s: s, logger: logger}
+}
+
+type loggingStatter struct {
+	s      Statter
+	logger *slog.Logger
+}
+
+func (ls loggingStatter) Stat(name string) (os.FileInfo, error) {
+	start := time.Now()
+	fi, err := ls.s.Stat(name)
+	ls.log("stat", name, start, err)
+	return fi, err
+}
+
+func (ls loggingStatter) Lstat(name string) (os.FileInfo, error) {
+	start := time.Now()
+	fi, err := ls.s.Lstat(name)
+	ls.log("lstat", name, start, err)
+	return fi, err
+}
+
+func (ls loggingStatter) ReadDir(name string) ([]os.FileInfo, error) {
+	start := time.Now()
+	list, err := readDir(ls.s, name)
+	ls.log("readdir", name, start, err)
+	return list, err
+}
+
+func (ls loggingStatter) Open(name string) (io.ReadCloser, error) {
+	o, ok := ls.s.(Opener)
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: errNotSupported}
+	}
+
+	start := time.Now()
+	rc, err := o.Open(name)
+	ls.log("open", name, start, err)
+	return rc, err
+}
+
+func (ls loggingStatter) Remove(name string) error {
+	start := time.Now()
+	err := remove(ls.s, name)
+	ls.log("remove", name, start, err)
+	return err
+}
+
+func (ls loggingStatter) RemoveAll(name string) error {
+	start := time.Now()
+	err := removeAll(ls.s, name)
+	ls.log("removeall", name, start, err)
+	return err
+}
+
+func (ls loggingStatter) Chtimes(name string, mtime time.Time) error {
+	start := time.Now()
+	err := chtimes(ls.s, name, mtime)
+	ls.log("chtimes", name, start, err)
+	return err
+}
+
+func (ls loggingStatter) Chmod(name string, mode os.FileMode) error {
+	start := time.Now()
+	err := chmod(ls.s, name, mode)
+	ls.log("chmod", name, start, err)
+	return err
+}
+
+func (ls loggingStatter) log(op, name string, start time.Time, err error) {
+	level := slog.LevelDebug
+	attrs := []slog.Attr{
+		slog.String("op", op),
+		slog.String("path", name),
+		slog.Duration("duration", time.Since(start)),
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+		if !os.IsNotExist(err) {
+			level = slog.LevelWarn
+		}
+	}
+
+	ls.logger.LogAttrs(context.Background(), level, "filemod", attrs...)
+}
@@ -0,0 +1,64 @@
+package filemod
+
+import (
+	"bytes"
+	"errors"
+	. "github.com/onsi/gomega"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestWithLogger(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == "duration" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	mem := NewMemFS(time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC))
+	g.Expect(mem.WriteFile("/d/a", []byte("a"), 0644)).To(Succeed())
+	mem.InjectError("/d/b", errors.New("boom"))
+	s := WithLogger(mem, logger)
+
+	// When...
+	files, err := WalkWith(s, "/d")
+	NewWith(s, "/d/b", "/d/c")
+	ContentHash(files[1])
+	files[:1].Prune(KeepNewest(0), false)
+
+	// Then...
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(buf.String()).To(Equal(
+		"level=DEBUG msg=filemod op=lstat path=/d\n" +
+			"level=DEBUG msg=filemod op=readdir path=/d\n" +
+			"level=WARN msg=filemod op=stat path=/d/b error=\"stat /d/b: boom\"\n" +
+			"level=DEBUG msg=filemod op=stat path=/d/c error=\"stat /d/c: file does not exist\"\n" +
+			"level=DEBUG msg=filemod op=open path=/d/a\n" +
+			"level=DEBUG msg=filemod op=removeall path=/d\n"))
+	g.Expect(StatWith(mem, "/d").Exists()).To(BeFalse())
+}
+
+func TestWithNilLogger(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	buf := &bytes.Buffer{}
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(buf, nil)))
+	mem := NewMemFS(time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC))
+	mem.InjectError("/a", errors.New("boom"))
+
+	// When...
+	file := StatWith(WithLogger(mem, nil), "/a")
+
+	// Then...
+	g.Expect(file.Err()).To(HaveOccurred())
+	g.Expect(buf.String()).To(ContainSubstring("level=WARN msg=filemod op=stat path=/a"))
+}
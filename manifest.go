@@ -0,0 +1,226 @@
+package filemod
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// ChecksumAlgorithm selects the hash function used in a checksum manifest.
+type ChecksumAlgorithm int
+
+const (
+	// SHA256 is compatible with the 'sha256sum' command.
+	SHA256 ChecksumAlgorithm = iota
+	// BLAKE2b is BLAKE2b-512, compatible with the 'b2sum' command.
+	BLAKE2b
+)
+
+func (alg ChecksumAlgorithm) String() string {
+	switch alg {
+	case SHA256:
+		return "sha256"
+	case BLAKE2b:
+		return "blake2b"
+	}
+	return fmt.Sprintf("ChecksumAlgorithm(%d)", int(alg))
+}
+
+func (alg ChecksumAlgorithm) new() hash.Hash {
+	if alg == BLAKE2b {
+		h, _ := blake2b.New512(nil) // cannot fail without a key
+		return h
+	}
+	return sha256.New()
+}
+
+// size is the length of the sums in hexadecimal.
+func (alg ChecksumAlgorithm) size() int {
+	return alg.new().Size() * 2
+}
+
+var (
+	// ErrChecksumMismatch is held in the errors from Verify for files whose content
+	// does not match the manifest.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+
+	// ErrNotInManifest is held in the errors from Verify for files that are not listed
+	// in the manifest.
+	ErrNotInManifest = errors.New("not in manifest")
+)
+
+//-------------------------------------------------------------------------------------------------
+
+// Manifest is a list of files with their checksums, in the format used by the
+// 'sha256sum' and 'b2sum' commands.
+type Manifest struct {
+	Algorithm ChecksumAlgorithm
+	Entries   []ManifestEntry
+}
+
+// ManifestEntry is one line of a Manifest.
+type ManifestEntry struct {
+	// Path is the slash-separated path of the file.
+	Path string
+	// Sum is the checksum of the file's content, in lower-case hexadecimal.
+	Sum string
+}
+
+// NewManifest computes the checksums of the files. Each file's path in the manifest is
+// given by the key; RelativeTo is typically used. Directories are skipped. Files that
+// do not exist or that cannot be read are returned as Errors.
+func NewManifest(files Files, alg ChecksumAlgorithm, key Key) (Manifest, error) {
+	key = orDefault(key)
+	m := Manifest{Algorithm: alg}
+	ee := missing(files)
+
+	for _, f := range files {
+		if !f.Exists() || f.IsDir() {
+			continue
+		}
+
+		Debug("%s %q\n", alg, f.path)
+		sum, err := hashFile(f, alg.new())
+		if err != nil {
+			ee = append(ee, err)
+			continue
+		}
+		m.Entries = append(m.Entries, ManifestEntry{Path: filepath.ToSlash(key(f)), Sum: sum})
+	}
+
+	if len(ee) > 0 {
+		return m, ee
+	}
+	return m, nil
+}
+
+// WriteTo writes the manifest in the format used by 'sha256sum' and 'b2sum'. Names
+// containing backslashes or line breaks are escaped in the same way as those commands.
+func (m Manifest) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for _, e := range m.Entries {
+		line := e.Sum + "  " + e.Path + "\n"
+		if strings.ContainsAny(e.Path, "\\\n\r") {
+			line = `\` + e.Sum + "  " + manifestEscaper.Replace(e.Path) + "\n"
+		}
+
+		n, err := io.WriteString(w, line)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+var (
+	manifestEscaper   = strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`)
+	manifestUnescaper = strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\r`, "\r")
+)
+
+// ReadManifest reads a manifest written by WriteTo, 'sha256sum' or 'b2sum'. The algorithm
+// is determined from the length of the checksums. Both text and binary mode lines
+// are accepted; blank lines are ignored.
+func ReadManifest(r io.Reader) (Manifest, error) {
+	var m Manifest
+	scanner := bufio.NewScanner(r)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+
+		escaped := strings.HasPrefix(line, `\`)
+		line = strings.TrimPrefix(line, `\`)
+
+		sum, name, ok := strings.Cut(line, " ")
+		if !ok || name == "" || (name[0] != ' ' && name[0] != '*') || !isHex(sum) {
+			return m, fmt.Errorf("manifest line %d: badly formatted", n)
+		}
+		name = name[1:]
+		if escaped {
+			name = manifestUnescaper.Replace(name)
+		}
+
+		switch {
+		case len(m.Entries) > 0 && len(sum) != m.Algorithm.size():
+			return m, fmt.Errorf("manifest line %d: checksum length %d does not match %s", n, len(sum), m.Algorithm)
+		case len(sum) == SHA256.size():
+			m.Algorithm = SHA256
+		case len(sum) == BLAKE2b.size():
+			m.Algorithm = BLAKE2b
+		default:
+			return m, fmt.Errorf("manifest line %d: unsupported checksum length %d", n, len(sum))
+		}
+
+		m.Entries = append(m.Entries, ManifestEntry{Path: name, Sum: strings.ToLower(sum)})
+	}
+
+	return m, scanner.Err()
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// Verify checks the files against the manifest, matching them by key as for NewManifest.
+// The discrepancies are returned as Errors, each being an *os.PathError holding
+//
+//   - ErrChecksumMismatch, for files whose content has changed,
+//   - os.ErrNotExist, for entries with no corresponding file, or
+//   - ErrNotInManifest, for regular files that are not listed in the manifest.
+//
+// Errors arising from reading the files are also included.
+func (m Manifest) Verify(files Files, key Key) error {
+	key = orDefault(key)
+	byPath := make(map[string]FileMetaInfo, len(files))
+	for _, f := range files {
+		byPath[filepath.ToSlash(key(f))] = f
+	}
+
+	var ee Errors
+	listed := make(map[string]bool, len(m.Entries))
+
+	for _, e := range m.Entries {
+		listed[e.Path] = true
+		f, exists := byPath[e.Path]
+		if !exists || !f.Exists() {
+			ee = append(ee, &os.PathError{Op: "verify", Path: e.Path, Err: os.ErrNotExist})
+			continue
+		}
+
+		Debug("%s %q\n", m.Algorithm, f.path)
+		sum, err := hashFile(f, m.Algorithm.new())
+		if err != nil {
+			ee = append(ee, err)
+		} else if sum != e.Sum {
+			ee = append(ee, &os.PathError{Op: "verify", Path: e.Path, Err: ErrChecksumMismatch})
+		}
+	}
+
+	for _, f := range files {
+		p := filepath.ToSlash(key(f))
+		if f.Mode().IsRegular() && f.Exists() && !listed[p] {
+			ee = append(ee, &os.PathError{Op: "verify", Path: p, Err: ErrNotInManifest})
+		}
+	}
+
+	if len(ee) > 0 {
+		return ee
+	}
+	return nil
+}
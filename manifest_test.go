@@ -0,0 +1,116 @@
+package filemod
+
+import (
+	"bytes"
+	"errors"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	sha256abc  = "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	blake2babc = "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d1" +
+		"7d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923"
+)
+
+func manifestTree(g *WithT) string {
+	dir := tempTree(g, "sub/")
+	for _, name := range []string{"a", "sub/b", `odd\name`} {
+		g.Expect(ioutil.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), []byte("abc"), 0644)).To(Succeed())
+	}
+	return dir
+}
+
+func TestManifestRoundTrip(t *testing.T) {
+	for _, alg := range []ChecksumAlgorithm{SHA256, BLAKE2b} {
+		g := NewGomegaWithT(t)
+		// Given...
+		fs = osFacade{} // the real deal
+		dir := manifestTree(g)
+		defer os.RemoveAll(dir)
+		files, err := Walk(dir)
+		g.Expect(err).NotTo(HaveOccurred())
+		sum := map[ChecksumAlgorithm]string{SHA256: sha256abc, BLAKE2b: blake2babc}[alg]
+
+		// When...
+		m, err := NewManifest(files, alg, RelativeTo(dir))
+		g.Expect(err).NotTo(HaveOccurred())
+		buf := &bytes.Buffer{}
+		_, err = m.WriteTo(buf)
+		g.Expect(err).NotTo(HaveOccurred())
+		text := buf.String()
+		m2, err := ReadManifest(strings.NewReader(text))
+
+		// Then...
+		g.Expect(err).NotTo(HaveOccurred())
+		expected := sum + "  a\n" +
+			`\` + sum + `  odd\\name` + "\n" +
+			sum + "  sub/b\n"
+		g.Expect(text).To(Equal(expected), alg.String())
+		g.Expect(m2).To(Equal(m))
+		g.Expect(m2.Verify(files, RelativeTo(dir))).To(Succeed())
+	}
+}
+
+func TestManifestVerifyReportsDiscrepancies(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	dir := manifestTree(g)
+	defer os.RemoveAll(dir)
+	m, err := ReadManifest(strings.NewReader(
+		sha256abc + "  a\n" +
+			"\n" +
+			strings.Repeat("0", 64) + " *sub/b\n" +
+			sha256abc + "  gone\n"))
+	g.Expect(err).NotTo(HaveOccurred())
+	files, err := Walk(dir)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// When...
+	err = m.Verify(files, RelativeTo(dir))
+
+	// Then...
+	g.Expect(err).To(HaveOccurred())
+	ee := err.(Errors)
+	g.Expect(ee).To(HaveLen(3))
+	g.Expect(errors.Is(ee[0], ErrChecksumMismatch)).To(BeTrue())
+	g.Expect(ee[0].(*os.PathError).Path).To(Equal("sub/b"))
+	g.Expect(errors.Is(ee[1], os.ErrNotExist)).To(BeTrue())
+	g.Expect(ee[1].(*os.PathError).Path).To(Equal("gone"))
+	g.Expect(errors.Is(ee[2], ErrNotInManifest)).To(BeTrue())
+	g.Expect(ee[2].(*os.PathError).Path).To(Equal(`odd\name`))
+}
+
+func TestReadManifestErrors(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	_, err := ReadManifest(strings.NewReader("xyz  a\n"))
+	g.Expect(err).To(MatchError("manifest line 1: badly formatted"))
+
+	_, err = ReadManifest(strings.NewReader("abcd  a\n"))
+	g.Expect(err).To(MatchError("manifest line 1: unsupported checksum length 4"))
+
+	_, err = ReadManifest(strings.NewReader(sha256abc + "  a\n" + blake2babc + "  b\n"))
+	g.Expect(err).To(MatchError("manifest line 2: checksum length 128 does not match sha256"))
+}
+
+func TestNewManifestMissingFile(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	dir := manifestTree(g)
+	defer os.RemoveAll(dir)
+
+	// When...
+	m, err := NewManifest(New(filepath.Join(dir, "a"), filepath.Join(dir, "nope")), SHA256, nil)
+
+	// Then...
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(errors.Is(err.(Errors)[0], os.ErrNotExist)).To(BeTrue())
+	g.Expect(m.Entries).To(HaveLen(1))
+}
@@ -0,0 +1,379 @@
+package filemod
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemFS is an in-memory filesystem, intended for testing code that uses filemod. It is a
+// Statter, a DirReader, an Opener, a Remover, a TimeChanger and a ModeChanger, so files
+// obtained from it are changed in memory, never on disk. It supports directories,
+// symbolic links, settable modification times, injected errors and a controllable clock.
+//
+// Paths are slash-separated; relative paths are treated as relative to the root "/".
+// It is safe for concurrent use.
+type MemFS struct {
+	mu     sync.Mutex
+	nodes  map[string]*memNode
+	errors map[string]error
+	now    time.Time
+}
+
+type memNode struct {
+	mode    os.FileMode
+	modTime time.Time
+	data    []byte
+	link    string
+}
+
+// NewMemFS creates an empty in-memory filesystem, containing only the root directory.
+// Its clock is set to the given time.
+func NewMemFS(now time.Time) *MemFS {
+	return &MemFS{
+		nodes:  map[string]*memNode{"/": {mode: os.ModeDir | 0755, modTime: now}},
+		errors: make(map[string]error),
+		now:    now,
+	}
+}
+
+// Now gets the current time of the filesystem's clock. This is the modification time
+// given to files and directories as they are created or written.
+func (m *MemFS) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+// SetTime sets the filesystem's clock.
+func (m *MemFS) SetTime(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = now
+}
+
+// Advance moves the filesystem's clock forward.
+func (m *MemFS) Advance(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.now = m.now.Add(d)
+}
+
+// InjectError causes every subsequent operation on a path to fail with an error.
+// Use a nil error to remove an injected error.
+func (m *MemFS) InjectError(name string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err == nil {
+		delete(m.errors, cleanMemPath(name))
+	} else {
+		m.errors[cleanMemPath(name)] = err
+	}
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// MkdirAll creates a directory, along with any necessary parents.
+func (m *MemFS) MkdirAll(name string, perm os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, err := m.mkdirAll(cleanMemPath(name), perm)
+	return err
+}
+
+func (m *MemFS) mkdirAll(name string, perm os.FileMode) (*memNode, error) {
+	if n, exists := m.nodes[name]; exists {
+		if !n.mode.IsDir() {
+			return nil, &os.PathError{Op: "mkdir", Path: name, Err: errNotDir}
+		}
+		return n, nil
+	}
+
+	parent, err := m.mkdirAll(path.Dir(name), perm)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &memNode{mode: os.ModeDir | perm.Perm(), modTime: m.now}
+	m.nodes[name] = n
+	parent.modTime = m.now
+	return n, nil
+}
+
+// WriteFile writes data to a file, creating it (and any necessary parent directories)
+// if necessary. A final symbolic link is followed, so its target is written. The
+// modification time is set from the clock.
+func (m *MemFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name = cleanMemPath(name)
+	for links := 0; ; links++ {
+		if err := m.errors[name]; err != nil {
+			return err
+		}
+		n, exists := m.nodes[name]
+		if !exists || n.link == "" {
+			break
+		}
+		if links == maxLinks {
+			return &os.PathError{Op: "write", Path: name, Err: errTooManyLinks}
+		}
+		target := n.link
+		if !path.IsAbs(target) {
+			target = path.Join(path.Dir(name), target)
+		}
+		name = cleanMemPath(target)
+	}
+
+	if n, exists := m.nodes[name]; exists {
+		if n.mode.IsDir() {
+			return &os.PathError{Op: "write", Path: name, Err: errIsDir}
+		}
+		n.data = append([]byte(nil), data...)
+		n.modTime = m.now
+		return nil
+	}
+
+	parent, err := m.mkdirAll(path.Dir(name), 0755)
+	if err != nil {
+		return err
+	}
+
+	m.nodes[name] = &memNode{mode: perm.Perm(), modTime: m.now, data: append([]byte(nil), data...)}
+	parent.modTime = m.now
+	return nil
+}
+
+// Symlink creates newname as a symbolic link to oldname.
+func (m *MemFS) Symlink(oldname, newname string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	newname = cleanMemPath(newname)
+	if _, exists := m.nodes[newname]; exists {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: os.ErrExist}
+	}
+
+	parent, err := m.mkdirAll(path.Dir(newname), 0755)
+	if err != nil {
+		return err
+	}
+
+	m.nodes[newname] = &memNode{mode: os.ModeSymlink | 0777, modTime: m.now, link: oldname}
+	parent.modTime = m.now
+	return nil
+}
+
+// Chtimes changes the modification time of a file, following symbolic links.
+func (m *MemFS) Chtimes(name string, mtime time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, n, err := m.resolve("chtimes", name, true)
+	if err != nil {
+		return err
+	}
+	n.modTime = mtime
+	return nil
+}
+
+// Chmod changes the permission bits of a file, following symbolic links.
+func (m *MemFS) Chmod(name string, mode os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, n, err := m.resolve("chmod", name, true)
+	if err != nil {
+		return err
+	}
+	n.mode = n.mode&os.ModeType | mode&(os.ModePerm|os.ModeSetuid|os.ModeSetgid|os.ModeSticky)
+	return nil
+}
+
+// Remove removes a file, symbolic link or empty directory.
+func (m *MemFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name, n, err := m.resolve("remove", name, false)
+	if err != nil {
+		return err
+	}
+	if name == "/" {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrPermission}
+	}
+	if n.mode.IsDir() && len(m.children(name)) > 0 {
+		return &os.PathError{Op: "remove", Path: name, Err: errNotEmpty}
+	}
+
+	delete(m.nodes, name)
+	if parent, exists := m.nodes[path.Dir(name)]; exists {
+		parent.modTime = m.now
+	}
+	return nil
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// Stat gets information about a file, following symbolic links.
+func (m *MemFS) Stat(name string) (os.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name, n, err := m.resolve("stat", name, true)
+	if err != nil {
+		return nil, err
+	}
+	return n.info(path.Base(name)), nil
+}
+
+// Lstat gets information about a file without following a final symbolic link.
+func (m *MemFS) Lstat(name string) (os.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name, n, err := m.resolve("lstat", name, false)
+	if err != nil {
+		return nil, err
+	}
+	return n.info(path.Base(name)), nil
+}
+
+// ReadDir lists the contents of a directory, in name order.
+func (m *MemFS) ReadDir(name string) ([]os.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name, n, err := m.resolve("readdir", name, true)
+	if err != nil {
+		return nil, err
+	}
+	if !n.mode.IsDir() {
+		return nil, &os.PathError{Op: "readdir", Path: name, Err: errNotDir}
+	}
+
+	children := m.children(name)
+	result := make([]os.FileInfo, len(children))
+	for i, c := range children {
+		result[i] = m.nodes[c].info(path.Base(c))
+	}
+	return result, nil
+}
+
+// Open opens a file for reading, following symbolic links.
+func (m *MemFS) Open(name string) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name, n, err := m.resolve("open", name, true)
+	if err != nil {
+		return nil, err
+	}
+	if n.mode.IsDir() {
+		return nil, &os.PathError{Op: "open", Path: name, Err: errIsDir}
+	}
+	return ioutil.NopCloser(bytes.NewReader(n.data)), nil
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// resolve finds a node, following symbolic links in every directory on the way and,
+// if required, the final symbolic link too. Injected errors apply to any path visited.
+func (m *MemFS) resolve(op, name string, followLast bool) (string, *memNode, error) {
+	pending := strings.Split(strings.TrimPrefix(cleanMemPath(name), "/"), "/")
+	current := "/"
+
+	for links := 0; len(pending) > 0; {
+		next := path.Join(current, pending[0])
+		pending = pending[1:]
+
+		if err := m.errors[next]; err != nil {
+			return next, nil, &os.PathError{Op: op, Path: next, Err: err}
+		}
+
+		n, exists := m.nodes[next]
+		if !exists {
+			return next, nil, &os.PathError{Op: op, Path: next, Err: os.ErrNotExist}
+		}
+
+		if n.link != "" && (len(pending) > 0 || followLast) {
+			links++
+			if links > maxLinks {
+				return next, nil, &os.PathError{Op: op, Path: next, Err: errTooManyLinks}
+			}
+			target := n.link
+			if !path.IsAbs(target) {
+				target = path.Join(current, target)
+			}
+			pending = append(strings.Split(strings.TrimPrefix(cleanMemPath(target), "/"), "/"), pending...)
+			current = "/"
+			continue
+		}
+
+		current = next
+	}
+
+	return current, m.nodes[current], nil
+}
+
+// children lists the paths of the entries in a directory, in name order.
+func (m *MemFS) children(dir string) []string {
+	var result []string
+	for name := range m.nodes {
+		if name != "/" && path.Dir(name) == dir {
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func cleanMemPath(name string) string {
+	return path.Clean("/" + filepath.ToSlash(name))
+}
+
+func (n *memNode) info(name string) os.FileInfo {
+	return memInfo{name: name, size: int64(len(n.data)), mode: n.mode, modTime: n.modTime}
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// memInfo is the information for a file in a MemFS.
+type memInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func (fi memInfo) Name() string {
+	return fi.name
+}
+
+func (fi memInfo) Size() int64 {
+	return fi.size
+}
+
+func (fi memInfo) Mode() os.FileMode {
+	return fi.mode
+}
+
+func (fi memInfo) ModTime() time.Time {
+	return fi.modTime
+}
+
+func (fi memInfo) IsDir() bool {
+	return fi.mode.IsDir()
+}
+
+func (fi memInfo) Sys() interface{} {
+	return nil
+}
@@ -0,0 +1,124 @@
+package filemod
+
+import (
+	"errors"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMemFS(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	t0 := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	mem := NewMemFS(t0)
+	g.Expect(mem.WriteFile("/src/a.go", []byte("package a"), 0644)).To(Succeed())
+	mem.Advance(time.Minute)
+	g.Expect(mem.WriteFile("/src/b.go", []byte("package b"), 0644)).To(Succeed())
+	g.Expect(mem.MkdirAll("/out", 0755)).To(Succeed())
+	g.Expect(mem.Symlink("../src/a.go", "/out/link")).To(Succeed())
+	g.Expect(mem.Symlink("/src", "/s")).To(Succeed())
+
+	// When...
+	files, err := WalkWith(mem, "/")
+
+	// Then...
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(paths(files)).To(Equal([]string{"/", "/out", "/out/link", "/s", "/src", "/src/a.go", "/src/b.go"}))
+	g.Expect(files[1].IsDir()).To(BeTrue())
+	g.Expect(files[2].Mode() & os.ModeSymlink).NotTo(BeZero())
+	g.Expect(files[4].ModTime()).To(Equal(t0.Add(time.Minute)))
+	g.Expect(files[5].ModTime()).To(Equal(t0))
+	g.Expect(files[5].Size()).To(BeEquivalentTo(9))
+
+	link := StatWith(mem, "/out/link")
+	g.Expect(link.Exists()).To(BeTrue())
+	g.Expect(link.Name()).To(Equal("a.go"))
+	g.Expect(link.ModTime()).To(Equal(t0))
+	g.Expect(StatWith(mem, "s/b.go").Size()).To(BeEquivalentTo(9))
+	g.Expect(StatWith(mem, "/src/c.go").Exists()).To(BeFalse())
+	g.Expect(StatWith(mem, "/src/c.go").Err()).To(BeNil())
+
+	rc, err := link.Open()
+	g.Expect(err).NotTo(HaveOccurred())
+	content, _ := ioutil.ReadAll(rc)
+	g.Expect(string(content)).To(Equal("package a"))
+}
+
+func TestMemFSTimesAndErrors(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	t0 := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	mem := NewMemFS(t0)
+	g.Expect(mem.WriteFile("a", nil, 0644)).To(Succeed())
+	g.Expect(mem.WriteFile("b", nil, 0644)).To(Succeed())
+	boom := errors.New("boom")
+
+	// When...
+	g.Expect(mem.Chtimes("a", t0.Add(-time.Hour))).To(Succeed())
+	mem.SetTime(t0.Add(time.Hour))
+	g.Expect(mem.WriteFile("b", []byte("b"), 0644)).To(Succeed())
+	mem.InjectError("/c", boom)
+
+	// Then...
+	g.Expect(mem.Now()).To(Equal(t0.Add(time.Hour)))
+	ab := NewWith(mem, "a", "b", "c")
+	g.Expect(ab[0].ModTime()).To(Equal(t0.Add(-time.Hour)))
+	g.Expect(ab[1].ModTime()).To(Equal(t0.Add(time.Hour)))
+	g.Expect(ab[2].Exists()).To(BeFalse())
+	g.Expect(errors.Is(ab[2].Err(), boom)).To(BeTrue())
+	g.Expect(ab[1:2].AllAreNewerThan(ab[:1])).To(BeTrue())
+
+	// When...
+	mem.InjectError("/c", nil)
+	g.Expect(mem.Remove("a")).To(Succeed())
+	g.Expect(mem.Remove("/")).NotTo(Succeed())
+
+	// Then...
+	g.Expect(StatWith(mem, "c").Err()).To(BeNil())
+	g.Expect(ab[0].Refresh().Exists()).To(BeFalse())
+}
+
+func TestMemFSChmod(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	mem := NewMemFS(time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC))
+	g.Expect(mem.WriteFile("/a", nil, 0644)).To(Succeed())
+	g.Expect(mem.Symlink("a", "/l")).To(Succeed())
+
+	// When...
+	err := mem.Chmod("/l", os.ModeSetuid|0755)
+
+	// Then...
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(StatWith(mem, "/a").Mode()).To(Equal(os.ModeSetuid | 0755))
+	g.Expect(LstatWith(mem, "/l").Mode() & os.ModeSymlink).NotTo(BeZero())
+	g.Expect(mem.Chmod("/nope", 0644)).To(MatchError(os.ErrNotExist))
+}
+
+func TestMemFSWriteThroughSymlink(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	mem := NewMemFS(time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC))
+	g.Expect(mem.WriteFile("/t", []byte("old"), 0644)).To(Succeed())
+	g.Expect(mem.Symlink("/t", "/l")).To(Succeed())
+	g.Expect(mem.Symlink("d/new", "/dangling")).To(Succeed())
+	g.Expect(mem.Symlink("/loop", "/loop")).To(Succeed())
+
+	// When...
+	err1 := mem.WriteFile("/l", []byte("new"), 0644)
+	err2 := mem.WriteFile("/dangling", []byte("created"), 0644)
+	err3 := mem.WriteFile("/loop", nil, 0644)
+
+	// Then...
+	g.Expect(err1).NotTo(HaveOccurred())
+	g.Expect(err2).NotTo(HaveOccurred())
+	g.Expect(err3).To(MatchError(errTooManyLinks))
+	content, _ := readFile(mem, "/t")
+	g.Expect(string(content)).To(Equal("new"))
+	g.Expect(LstatWith(mem, "/l").Mode() & os.ModeSymlink).NotTo(BeZero())
+	content, _ = readFile(mem, "/d/new")
+	g.Expect(string(content)).To(Equal("created"))
+}
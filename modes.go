@@ -0,0 +1,188 @@
+package filemod
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ErrModeNotSet is held in the errors from FixModes for files whose mode did not take
+// the requested value.
+var ErrModeNotSet = errors.New("mode was not set")
+
+// modeBits are the bits that can be changed by chmod.
+const modeBits = os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky
+
+// IsWorldWritable tests whether the file exists, is not a symbolic link and can be
+// written by anyone.
+func (file FileMetaInfo) IsWorldWritable() bool {
+	return file.Exists() && file.Mode()&os.ModeSymlink == 0 && file.Mode()&0002 != 0
+}
+
+// IsSetID tests whether the file exists and has its setuid or setgid bit set.
+func (file FileMetaInfo) IsSetID() bool {
+	return file.Exists() && file.Mode()&(os.ModeSetuid|os.ModeSetgid) != 0
+}
+
+// IsSticky tests whether the file exists and has its sticky bit set, which is normally
+// only meaningful for directories.
+func (file FileMetaInfo) IsSticky() bool {
+	return file.Exists() && file.Mode()&os.ModeSticky != 0
+}
+
+// IsScript tests whether the file is a regular file beginning with "#!". Its content
+// is read to find out; files that cannot be read are not scripts.
+func (file FileMetaInfo) IsScript() bool {
+	if !file.Exists() || !file.Mode().IsRegular() || file.Size() < 2 {
+		return false
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	buf := make([]byte, 2)
+	_, err = io.ReadFull(f, buf)
+	return err == nil && bytes.Equal(buf, []byte("#!"))
+}
+
+// IsNonExecutableScript tests whether the file is a script that nobody can execute.
+func (file FileMetaInfo) IsNonExecutableScript() bool {
+	return file.Mode()&0111 == 0 && file.IsScript()
+}
+
+// ModeMatches tests whether the file exists and its mode bits selected by the mask are
+// equal to those in want. For example, ModeMatches(0, 0022) tests that a file is not
+// writable by group or others. Symbolic links always match.
+func (file FileMetaInfo) ModeMatches(want, mask os.FileMode) bool {
+	if !file.Exists() {
+		return false
+	}
+	return file.Mode()&os.ModeSymlink != 0 || file.Mode()&mask&modeBits == want&mask&modeBits
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// WorldWritable returns only those files that can be written by anyone.
+func (files Files) WorldWritable() Files {
+	return files.Filter(FileMetaInfo.IsWorldWritable)
+}
+
+// SetID returns only those files that have their setuid or setgid bit set.
+func (files Files) SetID() Files {
+	return files.Filter(FileMetaInfo.IsSetID)
+}
+
+// Sticky returns only those files that have their sticky bit set.
+func (files Files) Sticky() Files {
+	return files.Filter(FileMetaInfo.IsSticky)
+}
+
+// NonExecutableScripts returns only those files that are scripts that nobody can execute.
+func (files Files) NonExecutableScripts() Files {
+	return files.Filter(FileMetaInfo.IsNonExecutableScript)
+}
+
+// NotMatchingMode returns only those files that exist and whose mode bits selected by
+// the mask differ from those in want.
+func (files Files) NotMatchingMode(want, mask os.FileMode) Files {
+	return files.Filter(func(f FileMetaInfo) bool {
+		return f.Exists() && !f.ModeMatches(want, mask)
+	})
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// ModeAudit reports files whose modes may be a security or usability problem.
+type ModeAudit struct {
+	WorldWritable        Files
+	SetID                Files
+	Sticky               Files
+	NonExecutableScripts Files
+	// WrongMode holds the files not matching the desired mode, if a mask was given.
+	WrongMode Files
+}
+
+// AuditModes checks the modes of the files. If mask is non-zero, files whose mode
+// bits selected by the mask differ from those in want are also reported.
+func (files Files) AuditModes(want, mask os.FileMode) ModeAudit {
+	audit := ModeAudit{
+		WorldWritable:        files.WorldWritable(),
+		SetID:                files.SetID(),
+		Sticky:               files.Sticky(),
+		NonExecutableScripts: files.NonExecutableScripts(),
+	}
+	if mask != 0 {
+		audit.WrongMode = files.NotMatchingMode(want, mask)
+	}
+	return audit
+}
+
+// Empty tests whether the audit found nothing to report.
+func (audit ModeAudit) Empty() bool {
+	return len(audit.WorldWritable)+len(audit.SetID)+len(audit.Sticky)+
+		len(audit.NonExecutableScripts)+len(audit.WrongMode) == 0
+}
+
+// String lists the files found, grouped by problem.
+func (audit ModeAudit) String() string {
+	buf := &strings.Builder{}
+	section := func(title string, files Files) {
+		if len(files) > 0 {
+			fmt.Fprintf(buf, "%s:\n", title)
+			for _, f := range files {
+				fmt.Fprintf(buf, "  %v %s\n", f.Mode(), f.path)
+			}
+		}
+	}
+
+	section("world-writable", audit.WorldWritable)
+	section("setuid/setgid", audit.SetID)
+	section("sticky", audit.Sticky)
+	section("non-executable scripts", audit.NonExecutableScripts)
+	section("wrong mode", audit.WrongMode)
+	return buf.String()
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// FixModes sets the mode bits selected by the mask to those in want, for every file that
+// does not already match, leaving the other bits alone. For example, FixModes(0, 0022)
+// removes write permission for group and others, and FixModes(0111, 0111) makes files
+// executable. Each mode is changed through the file's own Statter, which must be a
+// ModeChanger.
+//
+// The result holds the files whose modes were corrected, refreshed. Files that could
+// not be changed, or that still do not match afterwards, are reported as Errors.
+// Symbolic links always match, so their targets are never changed.
+func (files Files) FixModes(want, mask os.FileMode) (Files, error) {
+	var changed Files
+	var ee Errors
+
+	for _, f := range files.NotMatchingMode(want, mask) {
+		mode := f.Mode()&modeBits&^mask | want&mask&modeBits
+
+		Debug("chmod %q %v\n", f.path, mode)
+		if err := chmod(f.statter(), f.path, mode); err != nil {
+			ee = append(ee, err)
+			continue
+		}
+
+		f = f.Refresh()
+		if !f.ModeMatches(want, mask) {
+			ee = append(ee, &os.PathError{Op: "chmod", Path: f.path, Err: ErrModeNotSet})
+			continue
+		}
+		changed = append(changed, f)
+	}
+
+	if len(ee) > 0 {
+		return changed, ee
+	}
+	return changed, nil
+}
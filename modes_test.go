@@ -0,0 +1,111 @@
+package filemod
+
+import (
+	"errors"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestModePredicates(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	ww := fileInfo{name: "ww", mode: 0666}
+	suid := fileInfo{name: "suid", mode: os.ModeSetuid | 0755}
+	sgid := fileInfo{name: "sgid", mode: os.ModeSetgid | 0750}
+	tmp := fileInfo{name: "tmp", mode: os.ModeDir | os.ModeSticky | 0777, isDir: true}
+	link := fileInfo{name: "link", mode: os.ModeSymlink | 0777}
+	ok := fileInfo{name: "ok", mode: 0644}
+	fs = &osStub{[]fileInfo{ww, suid, sgid, tmp, link, ok}} // global
+	files := New("ww", "suid", "sgid", "tmp", "link", "ok")
+
+	// When...
+	audit := files.AuditModes(0, 0022)
+
+	// Then...
+	g.Expect(paths(audit.WorldWritable)).To(Equal([]string{"ww", "tmp"}))
+	g.Expect(paths(audit.SetID)).To(Equal([]string{"suid", "sgid"}))
+	g.Expect(paths(audit.Sticky)).To(Equal([]string{"tmp"}))
+	g.Expect(audit.NonExecutableScripts).To(BeEmpty())
+	g.Expect(paths(audit.WrongMode)).To(Equal([]string{"ww", "tmp"}))
+	g.Expect(audit.Empty()).To(BeFalse())
+	g.Expect(audit.String()).To(Equal(
+		"world-writable:\n" +
+			"  -rw-rw-rw- ww\n" +
+			"  dtrwxrwxrwx tmp\n" +
+			"setuid/setgid:\n" +
+			"  urwxr-xr-x suid\n" +
+			"  grwxr-x--- sgid\n" +
+			"sticky:\n" +
+			"  dtrwxrwxrwx tmp\n" +
+			"wrong mode:\n" +
+			"  -rw-rw-rw- ww\n" +
+			"  dtrwxrwxrwx tmp\n"))
+
+	g.Expect(files[1].ModeMatches(os.ModeSetuid, os.ModeSetuid)).To(BeTrue())
+	g.Expect(files[5].ModeMatches(0600, 0777)).To(BeFalse())
+	g.Expect(files[5:].AuditModes(0, 0).Empty()).To(BeTrue())
+}
+
+func TestScriptsAndFixModes(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	dir := tempTree(g, "data")
+	defer os.RemoveAll(dir)
+	for name, mode := range map[string]os.FileMode{"a.sh": 0644, "b.sh": 0755} {
+		g.Expect(ioutil.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), mode)).To(Succeed())
+		g.Expect(os.Chmod(filepath.Join(dir, name), mode)).To(Succeed())
+	}
+	g.Expect(os.Chmod(filepath.Join(dir, "data"), 0666)).To(Succeed())
+	files, err := Walk(dir)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// When...
+	scripts := files.NonExecutableScripts()
+	fixedScripts, err1 := scripts.FixModes(0111, 0111)
+	fixedWritable, err2 := files.FilesOnly().FixModes(0, 0022)
+
+	// Then...
+	g.Expect(relPaths(dir, scripts)).To(Equal([]string{"a.sh"}))
+	g.Expect(err1).NotTo(HaveOccurred())
+	g.Expect(fixedScripts[0].Mode().Perm()).To(Equal(os.FileMode(0755)))
+	g.Expect(err2).NotTo(HaveOccurred())
+	g.Expect(relPaths(dir, fixedWritable)).To(Equal([]string{"data"}))
+	g.Expect(fixedWritable[0].Mode().Perm()).To(Equal(os.FileMode(0644)))
+	after, _ := Walk(dir)
+	g.Expect(after.NonExecutableScripts()).To(BeEmpty())
+	g.Expect(after.WorldWritable()).To(BeEmpty())
+}
+
+func TestFixModesUsesStatter(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	mem := NewMemFS(time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC))
+	g.Expect(mem.WriteFile("/run.sh", []byte("#!/bin/sh\n"), 0666)).To(Succeed())
+	files := NewWith(mem, "/run.sh")
+	fs = osFacade{} // the real deal
+	dir := archiveTree(g)
+	defer os.RemoveAll(dir)
+	arcName := dir + ".tar"
+	writeTar(g, arcName, false)
+	defer os.Remove(arcName)
+	arc, err := OpenArchive(arcName)
+	g.Expect(err).NotTo(HaveOccurred())
+	defer arc.Close()
+
+	// When...
+	fixed, err1 := files.FixModes(0755, 0777)
+	_, err2 := NewWith(arc, "a").FixModes(0755, 0777)
+
+	// Then...
+	g.Expect(err1).NotTo(HaveOccurred())
+	g.Expect(paths(fixed)).To(Equal([]string{"/run.sh"}))
+	g.Expect(StatWith(mem, "/run.sh").Mode()).To(Equal(os.FileMode(0755)))
+	g.Expect(StatWith(mem, "/run.sh").IsNonExecutableScript()).To(BeFalse())
+	g.Expect(err2).To(HaveOccurred())
+	g.Expect(errors.Is(err2.(Errors)[0], errNotSupported)).To(BeTrue())
+}
@@ -1,13 +1,59 @@
 package filemod
 
-import "os"
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
 
-// statter is a seam for testing
-type statter interface {
+// Statter provides information about files. By default, the operating system is used;
+// other implementations provide access to archives, for example.
+type Statter interface {
 	Stat(name string) (os.FileInfo, error)
 	Lstat(name string) (os.FileInfo, error)
 }
 
+// DirReader is implemented by Statters that can list the contents of directories.
+type DirReader interface {
+	ReadDir(name string) ([]os.FileInfo, error)
+}
+
+// Opener is implemented by Statters that can read the content of files.
+type Opener interface {
+	Open(name string) (io.ReadCloser, error)
+}
+
+// Remover is implemented by Statters that can delete files, symbolic links and empty
+// directories.
+type Remover interface {
+	Remove(name string) error
+}
+
+// TimeChanger is implemented by Statters that can set the modification times of files,
+// following symbolic links.
+type TimeChanger interface {
+	Chtimes(name string, mtime time.Time) error
+}
+
+// ModeChanger is implemented by Statters that can set the permission bits of files,
+// following symbolic links.
+type ModeChanger interface {
+	Chmod(name string, mode os.FileMode) error
+}
+
+var (
+	// errNotSupported arises when the Statter in use lacks an optional capability.
+	errNotSupported = errors.New("operation not supported")
+
+	errTooManyLinks = errors.New("too many links")
+	errNotDir       = errors.New("not a directory")
+	errIsDir        = errors.New("is a directory")
+	errNotEmpty     = errors.New("directory not empty")
+)
+
 type osFacade struct{}
 
 func (o osFacade) Stat(name string) (os.FileInfo, error) {
@@ -18,4 +64,95 @@ func (o osFacade) Lstat(name string) (os.FileInfo, error) {
 	return os.Lstat(name)
 }
 
-var fs statter = osFacade{}
+func (o osFacade) ReadDir(name string) ([]os.FileInfo, error) {
+	return ioutil.ReadDir(name)
+}
+
+func (o osFacade) Open(name string) (io.ReadCloser, error) {
+	return os.Open(name)
+}
+
+func (o osFacade) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (o osFacade) RemoveAll(name string) error {
+	return os.RemoveAll(name)
+}
+
+func (o osFacade) Chtimes(name string, mtime time.Time) error {
+	return os.Chtimes(name, mtime, mtime)
+}
+
+func (o osFacade) Chmod(name string, mode os.FileMode) error {
+	return os.Chmod(name, mode)
+}
+
+// OS is the Statter that uses the operating system.
+var OS Statter = osFacade{}
+
+// fs is a seam for testing
+var fs Statter = OS
+
+// readFile reads the whole content of a file, using a Statter that is also an Opener.
+func readFile(s Statter, name string) ([]byte, error) {
+	o, ok := s.(Opener)
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: errNotSupported}
+	}
+
+	f, err := o.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ioutil.ReadAll(f)
+}
+
+// remove deletes a file, using a Statter that is also a Remover.
+func remove(s Statter, name string) error {
+	r, ok := s.(Remover)
+	if !ok {
+		return &os.PathError{Op: "remove", Path: name, Err: errNotSupported}
+	}
+	return r.Remove(name)
+}
+
+// removeAll deletes a directory and its contents, using a Statter that is also a Remover
+// and a DirReader, unless it can do this itself.
+func removeAll(s Statter, name string) error {
+	if ra, ok := s.(interface{ RemoveAll(string) error }); ok {
+		return ra.RemoveAll(name)
+	}
+
+	if fi, err := s.Lstat(name); err == nil && fi.IsDir() {
+		list, err := readDir(s, name)
+		if err != nil {
+			return err
+		}
+		for _, fi := range list {
+			if err = removeAll(s, filepath.Join(name, fi.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return remove(s, name)
+}
+
+// chtimes sets the modification time of a file, using a Statter that is also a TimeChanger.
+func chtimes(s Statter, name string, mtime time.Time) error {
+	tc, ok := s.(TimeChanger)
+	if !ok {
+		return &os.PathError{Op: "chtimes", Path: name, Err: errNotSupported}
+	}
+	return tc.Chtimes(name, mtime)
+}
+
+// chmod sets the mode of a file, using a Statter that is also a ModeChanger.
+func chmod(s Statter, name string, mode os.FileMode) error {
+	mc, ok := s.(ModeChanger)
+	if !ok {
+		return &os.PathError{Op: "chmod", Path: name, Err: errNotSupported}
+	}
+	return mc.Chmod(name, mode)
+}
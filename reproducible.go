@@ -0,0 +1,131 @@
+package filemod
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrTimeNotSet is held in the errors from Clamp and Normalise for files whose
+	// modification time did not take the requested value.
+	ErrTimeNotSet = errors.New("modification time was not set")
+
+	// ErrAfterEpoch is held in the errors from AuditEpoch for files modified after the epoch.
+	ErrAfterEpoch = errors.New("modified after SOURCE_DATE_EPOCH")
+)
+
+// SourceDateEpoch gets the time given by the SOURCE_DATE_EPOCH environment variable,
+// which is a number of seconds since the Unix epoch. If the variable is not set, the
+// result is the zero time. See https://reproducible-builds.org/specs/source-date-epoch/
+func SourceDateEpoch() (time.Time, error) {
+	s, exists := os.LookupEnv("SOURCE_DATE_EPOCH")
+	if !exists || s == "" {
+		return time.Time{}, nil
+	}
+
+	secs, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("SOURCE_DATE_EPOCH: %w", err)
+	}
+	return time.Unix(secs, 0).UTC(), nil
+}
+
+// Clamp moves the modification time of every file that is newer than the epoch back to
+// the epoch itself, as required for reproducible builds; older files keep their times.
+// Each file is changed through its own Statter, which must be a TimeChanger.
+//
+// The files that were moved back are returned, refreshed. Any that could not be
+// changed, or whose new time did not stick, are reported as Errors. Symbolic links and
+// missing files are skipped.
+func (files Files) Clamp(epoch time.Time) (Files, error) {
+	return files.setTimes(epoch, func(f FileMetaInfo) bool {
+		return f.ModTime().After(epoch)
+	})
+}
+
+// Normalise gives every file the same modification time t, typically the source date
+// epoch, so that nothing built from them depends on when they were written. Unlike
+// Clamp, it also moves older files forward.
+//
+// As with Clamp, the result holds the files whose times differed from t, refreshed,
+// and the failures are reported as Errors.
+func (files Files) Normalise(t time.Time) (Files, error) {
+	return files.setTimes(t, func(f FileMetaInfo) bool {
+		return !f.ModTime().Equal(t)
+	})
+}
+
+func (files Files) setTimes(t time.Time, p func(FileMetaInfo) bool) (Files, error) {
+	var changed Files
+	var ee Errors
+
+	for _, f := range files {
+		if !f.Exists() || f.Mode()&os.ModeSymlink != 0 || !p(f) {
+			continue
+		}
+
+		Debug("chtimes %q %s\n", f.path, t.Format(time.RFC3339))
+		if err := chtimes(f.statter(), f.path, t); err != nil {
+			ee = append(ee, err)
+			continue
+		}
+
+		f = f.Refresh()
+		if !f.ModTime().Equal(t) {
+			ee = append(ee, &os.PathError{Op: "chtimes", Path: f.path, Err: ErrTimeNotSet})
+			continue
+		}
+		changed = append(changed, f)
+	}
+
+	if len(ee) > 0 {
+		return changed, ee
+	}
+	return changed, nil
+}
+
+// AuditEpoch checks that no file was modified after the epoch. Each file that was is
+// reported as an error holding ErrAfterEpoch; together, these are returned as Errors.
+func (files Files) AuditEpoch(epoch time.Time) error {
+	var ee Errors
+	for _, f := range files {
+		if f.Exists() && f.ModTime().After(epoch) {
+			ee = append(ee, &os.PathError{Op: "audit", Path: f.path,
+				Err: fmt.Errorf("%w: %s is after %s", ErrAfterEpoch, formatTime(f.ModTime()), formatTime(epoch))})
+		}
+	}
+
+	if len(ee) > 0 {
+		return ee
+	}
+	return nil
+}
+
+// StaleClamped is like Stale, for files whose times have been clamped or normalised to
+// the epoch. The times of inputs and outputs are clamped to the epoch before they are
+// compared, and an output whose time equals that of the newest input is up to date.
+//
+// Changes made to inputs after the epoch are therefore not seen until the epoch moves
+// on, which happens when it is derived from the latest commit, as is conventional.
+func StaleClamped(outputs, inputs Files, epoch time.Time) Files {
+	var newest time.Time
+	for _, f := range inputs {
+		if t := clampTime(f.ModTime(), epoch); t.After(newest) {
+			newest = t
+		}
+	}
+
+	return outputs.Filter(func(f FileMetaInfo) bool {
+		return !f.Exists() || clampTime(f.ModTime(), epoch).Before(newest)
+	})
+}
+
+func clampTime(t, epoch time.Time) time.Time {
+	if t.After(epoch) {
+		return epoch
+	}
+	return t
+}
@@ -0,0 +1,132 @@
+package filemod
+
+import (
+	"errors"
+	. "github.com/onsi/gomega"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSourceDateEpoch(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	t.Setenv("SOURCE_DATE_EPOCH", "1577880000")
+	epoch, err := SourceDateEpoch()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(epoch).To(Equal(time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)))
+
+	t.Setenv("SOURCE_DATE_EPOCH", "")
+	epoch, err = SourceDateEpoch()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(epoch.IsZero()).To(BeTrue())
+
+	t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+	_, err = SourceDateEpoch()
+	g.Expect(err).To(HaveOccurred())
+}
+
+func TestClampAndAudit(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	dir := tempTree(g, "old", "new", "d/")
+	defer os.RemoveAll(dir)
+	epoch := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	before := epoch.Add(-time.Hour)
+	g.Expect(os.Chtimes(filepath.Join(dir, "old"), before, before)).To(Succeed())
+	files, err := Walk(dir)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// When...
+	auditBefore := files.AuditEpoch(epoch)
+	changed, err := files.Clamp(epoch)
+	g.Expect(err).NotTo(HaveOccurred())
+	auditAfter := New(paths(files)...).AuditEpoch(epoch)
+
+	// Then...
+	g.Expect(auditBefore).To(HaveLen(3))
+	g.Expect(errors.Is(auditBefore.(Errors)[0], ErrAfterEpoch)).To(BeTrue())
+	g.Expect(paths(changed)).To(ConsistOf(dir, filepath.Join(dir, "d"), filepath.Join(dir, "new")))
+	g.Expect(auditAfter).NotTo(HaveOccurred())
+	g.Expect(Stat(filepath.Join(dir, "old")).ModTime()).To(BeTemporally("==", before))
+}
+
+func TestNormalise(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	dir := tempTree(g, "a", "b")
+	defer os.RemoveAll(dir)
+	epoch := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	files := New(filepath.Join(dir, "a"), filepath.Join(dir, "b"), filepath.Join(dir, "missing"))
+
+	// When...
+	changed, err := files.Normalise(epoch)
+
+	// Then...
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(changed).To(HaveLen(2))
+	for _, f := range changed {
+		g.Expect(f.ModTime()).To(BeTemporally("==", epoch))
+	}
+
+	// When...
+	changed, err = changed.Normalise(epoch)
+
+	// Then...
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(changed).To(BeEmpty())
+}
+
+func TestStaleClamped(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	epoch := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := fileInfo{name: "at", modTime: epoch}
+	early := fileInfo{name: "early", modTime: epoch.Add(-time.Hour)}
+	late := fileInfo{name: "late", modTime: epoch.Add(time.Hour)}
+	fs = &osStub{[]fileInfo{at, early, fileInfo{err: os.ErrNotExist}, late, at}} // global
+	outputs := New("at", "early", "missing")
+	inputs := New("late", "at")
+
+	// When...
+	stale := StaleClamped(outputs, inputs, epoch)
+	plain := Stale(outputs, inputs)
+
+	// Then...
+	g.Expect(paths(stale)).To(Equal([]string{"early", "missing"}))
+	g.Expect(paths(plain)).To(Equal([]string{"at", "early", "missing"}))
+}
+
+func TestNormaliseUsesStatter(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	epoch := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	mem := NewMemFS(epoch.Add(time.Hour))
+	g.Expect(mem.WriteFile("/a", []byte("a"), 0644)).To(Succeed())
+	g.Expect(mem.Symlink("/a", "/l")).To(Succeed())
+	files, err := WalkWith(mem, "/")
+	g.Expect(err).NotTo(HaveOccurred())
+	fs = osFacade{} // the real deal
+	dir := archiveTree(g)
+	defer os.RemoveAll(dir)
+	arcName := dir + ".tar"
+	writeTar(g, arcName, false)
+	defer os.Remove(arcName)
+	arc, err := OpenArchive(arcName)
+	g.Expect(err).NotTo(HaveOccurred())
+	defer arc.Close()
+
+	// When...
+	changed, err1 := files.Clamp(epoch)
+	_, err2 := NewWith(arc, "a").Normalise(epoch.Add(-time.Hour))
+
+	// Then...
+	g.Expect(err1).NotTo(HaveOccurred())
+	g.Expect(paths(changed)).To(Equal([]string{"/", "/a"}))
+	g.Expect(StatWith(mem, "/a").ModTime()).To(BeTemporally("==", epoch))
+	g.Expect(err2).To(HaveOccurred())
+	g.Expect(errors.Is(err2.(Errors)[0], errNotSupported)).To(BeTrue())
+}
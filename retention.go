@@ -0,0 +1,129 @@
+package filemod
+
+import (
+	"path/filepath"
+	"time"
+)
+
+// Policy is a retention policy. Given a list of files, it selects those that should be
+// deleted. Only files that exist are ever selected. Policies do not alter the list they
+// are given.
+type Policy func(files Files) Files
+
+// KeepNewest is a policy that keeps the newest n files and deletes the rest. A negative
+// n is treated as zero.
+func KeepNewest(n int) Policy {
+	if n < 0 {
+		n = 0
+	}
+	return func(files Files) Files {
+		present := files.PresentOnly().SortedByModTime()
+		if len(present) <= n {
+			return nil
+		}
+		return present[:len(present)-n]
+	}
+}
+
+// DeleteOlderThan is a policy that deletes files last modified longer ago than the age d,
+// according to Now.
+func DeleteOlderThan(d time.Duration) Policy {
+	return func(files Files) Files {
+		return files.OlderThanDuration(d).SortedByModTime()
+	}
+}
+
+// KeepTotalSizeUnder is a policy that keeps the newest files whose total size is no
+// more than max bytes, deleting all older files.
+func KeepTotalSizeUnder(max int64) Policy {
+	return func(files Files) Files {
+		present := files.PresentOnly().SortedByModTime()
+		total := int64(0)
+		for i := len(present) - 1; i >= 0; i-- {
+			total += present[i].Size()
+			if total > max {
+				return present[:i+1]
+			}
+		}
+		return nil
+	}
+}
+
+// PerGroup is a policy that applies another policy separately to each group of files,
+// grouping the files by key. For example, PerGroup(ParentDir, KeepNewest(3)) keeps the
+// newest three files in every directory.
+func PerGroup(key Key, policy Policy) Policy {
+	return func(files Files) Files {
+		var order []string
+		groups := make(map[string]Files)
+		for _, f := range files {
+			k := key(f)
+			if _, exists := groups[k]; !exists {
+				order = append(order, k)
+			}
+			groups[k] = append(groups[k], f)
+		}
+
+		var result Files
+		for _, k := range order {
+			result = append(result, policy(groups[k])...)
+		}
+		return result.SortedByModTime()
+	}
+}
+
+// AnyOf is a policy that deletes files selected by any of the policies.
+func AnyOf(policies ...Policy) Policy {
+	return func(files Files) Files {
+		var result Files
+		for _, p := range policies {
+			result = result.Union(p(files), nil)
+		}
+		return result.SortedByModTime()
+	}
+}
+
+// ParentDir is a Key that groups files by the directory containing them.
+func ParentDir(file FileMetaInfo) string {
+	return filepath.Dir(file.path)
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// Prune applies a retention policy, deleting the files it selects, oldest first.
+// Directories are deleted along with their contents. The files are deleted using the
+// Statter that provided their information, which must be a Remover.
+//
+// If dryRun is true, nothing is deleted and the planned deletions are returned.
+// Otherwise, the files that were deleted are returned and any failures are
+// returned as Errors.
+func (files Files) Prune(policy Policy, dryRun bool) (Files, error) {
+	planned := policy(files)
+	if dryRun {
+		return planned, nil
+	}
+
+	deleted := make(Files, 0, len(planned))
+	var ee Errors
+
+	for _, f := range planned {
+		Debug("delete %q\n", f.path)
+		var err error
+		if f.IsDir() {
+			err = removeAll(f.statter(), f.path)
+		} else {
+			err = remove(f.statter(), f.path)
+		}
+
+		if err != nil {
+			ee = append(ee, err)
+		} else {
+			deleted = append(deleted, f)
+		}
+	}
+
+	if len(ee) > 0 {
+		return deleted, ee
+	}
+	return deleted, nil
+}
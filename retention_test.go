@@ -0,0 +1,161 @@
+package filemod
+
+import (
+	"errors"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func retentionFixture() Files {
+	now := time.Now().UTC()
+	a1 := fileInfo{name: "a1", size: 100, modTime: now.Add(-1 * time.Hour)}
+	a2 := fileInfo{name: "a2", size: 200, modTime: now.Add(-2 * time.Hour)}
+	a3 := fileInfo{name: "a3", size: 300, modTime: now.Add(-3 * time.Hour)}
+	b1 := fileInfo{name: "b1", size: 400, modTime: now.Add(-4 * time.Hour)}
+	b2 := fileInfo{name: "b2", size: 500, modTime: now.Add(-5 * time.Hour)}
+	x := fileInfo{err: os.ErrNotExist}
+	fs = &osStub{[]fileInfo{a1, b1, a2, x, b2, a3}} // global
+	return New("/a/1", "/b/1", "/a/2", "/a/x", "/b/2", "/a/3")
+}
+
+func TestKeepNewest(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	files := retentionFixture()
+
+	// When...
+	planned := KeepNewest(2)(files)
+
+	// Then...
+	g.Expect(paths(planned)).To(Equal([]string{"/b/2", "/b/1", "/a/3"}))
+	g.Expect(files[0].Path()).To(Equal("/a/1")) // unaltered
+	g.Expect(KeepNewest(5)(files)).To(BeEmpty())
+	g.Expect(KeepNewest(-1)(files)).To(HaveLen(5))
+}
+
+func TestDeleteOlderThan(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	files := retentionFixture()
+
+	// When...
+	planned := DeleteOlderThan(210 * time.Minute)(files)
+
+	// Then...
+	g.Expect(paths(planned)).To(Equal([]string{"/b/2", "/b/1"}))
+}
+
+func TestKeepTotalSizeUnder(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	files := retentionFixture()
+
+	// When...
+	planned := KeepTotalSizeUnder(600)(files)
+
+	// Then...
+	g.Expect(paths(planned)).To(Equal([]string{"/b/2", "/b/1"}))
+	g.Expect(KeepTotalSizeUnder(1500)(files)).To(BeEmpty())
+}
+
+func TestPerGroupAndAnyOf(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	files := retentionFixture()
+
+	// When...
+	perDir := PerGroup(ParentDir, KeepNewest(1))(files)
+	either := AnyOf(KeepNewest(4), DeleteOlderThan(150*time.Minute))(files)
+
+	// Then...
+	g.Expect(paths(perDir)).To(Equal([]string{"/b/2", "/a/3", "/a/2"}))
+	g.Expect(paths(either)).To(Equal([]string{"/b/2", "/b/1", "/a/3"}))
+}
+
+func TestPrune(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	dir := tempTree(g, "a", "b", "c/d")
+	defer os.RemoveAll(dir)
+	for i, name := range []string{"a", "b", "c"} {
+		then := time.Now().Add(time.Duration(i-10) * time.Hour)
+		g.Expect(os.Chtimes(filepath.Join(dir, name), then, then)).To(Succeed())
+	}
+	files := New(filepath.Join(dir, "a"), filepath.Join(dir, "b"), filepath.Join(dir, "c"))
+
+	// When...
+	planned, err1 := files.Prune(KeepNewest(1), true)
+	deleted, err2 := files.Prune(KeepNewest(1), false)
+	remaining, _ := ioutil.ReadDir(dir)
+
+	// Then...
+	g.Expect(err1).NotTo(HaveOccurred())
+	g.Expect(err2).NotTo(HaveOccurred())
+	g.Expect(paths(planned)).To(Equal(paths(files[:2])))
+	g.Expect(paths(deleted)).To(Equal(paths(files[:2])))
+	g.Expect(remaining).To(HaveLen(1))
+	g.Expect(remaining[0].Name()).To(Equal("c"))
+
+	// When...
+	deleted, err3 := files.Prune(KeepNewest(1), false)
+
+	// Then...
+	g.Expect(deleted).To(BeEmpty())
+	g.Expect(err3).To(HaveOccurred())
+	g.Expect(err3.(Errors)).To(HaveLen(2))
+}
+
+func TestPruneUsesStatter(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	dir := tempTree(g, "victim")
+	defer os.RemoveAll(dir)
+	victim := filepath.Join(dir, "victim")
+	t0 := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	mem := NewMemFS(t0)
+	g.Expect(mem.WriteFile(victim, []byte("old"), 0644)).To(Succeed())
+	g.Expect(mem.WriteFile("/old/a/b", []byte("old"), 0644)).To(Succeed())
+	mem.Advance(time.Hour)
+	g.Expect(mem.WriteFile("/new", []byte("new"), 0644)).To(Succeed())
+	g.Expect(mem.Chtimes("/old", t0)).To(Succeed())
+	files := NewWith(mem, victim, "/old", "/new")
+
+	// When...
+	deleted, err := files.Prune(KeepNewest(1), false)
+
+	// Then...
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(paths(deleted)).To(ConsistOf(victim, "/old"))
+	g.Expect(StatWith(mem, victim).Exists()).To(BeFalse())
+	g.Expect(StatWith(mem, "/old/a").Exists()).To(BeFalse())
+	g.Expect(StatWith(mem, "/new").Exists()).To(BeTrue())
+	g.Expect(victim).To(BeAnExistingFile()) // on disk, untouched
+}
+
+func TestPruneNotSupported(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	dir := archiveTree(g)
+	defer os.RemoveAll(dir)
+	arcName := dir + ".tar"
+	writeTar(g, arcName, false)
+	defer os.Remove(arcName)
+	arc, err := OpenArchive(arcName)
+	g.Expect(err).NotTo(HaveOccurred())
+	defer arc.Close()
+
+	// When...
+	deleted, err := NewWith(arc, "a", "d/b").Prune(KeepNewest(0), false)
+
+	// Then...
+	g.Expect(deleted).To(BeEmpty())
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(errors.Is(err.(Errors)[0], errNotSupported)).To(BeTrue())
+}
@@ -0,0 +1,139 @@
+package filemod
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"path/filepath"
+)
+
+// Key derives the identity of a file. It is used when lists of files are treated
+// as sets: two files with the same key are considered to be the same file.
+type Key func(FileMetaInfo) string
+
+// CleanPath is a Key that identifies files by their lexically-cleaned path.
+// This is the default used when a nil Key is supplied.
+func CleanPath(file FileMetaInfo) string {
+	return filepath.Clean(file.path)
+}
+
+// AbsPath is a Key that identifies files by their absolute path. If the absolute
+// path cannot be determined, the cleaned path is used instead.
+func AbsPath(file FileMetaInfo) string {
+	abs, err := filepath.Abs(file.path)
+	if err != nil {
+		return CleanPath(file)
+	}
+	return abs
+}
+
+// Inode is a Key that identifies files by their device and inode numbers, so
+// hard links to the same file are considered equal. For files that do not exist,
+// or on platforms that do not provide inode numbers, the absolute path is used instead.
+func Inode(file FileMetaInfo) string {
+	if file.Exists() {
+		if dev, ino, ok := inodeOf(file.fi); ok {
+			return fmt.Sprintf("inode:%d:%d", dev, ino)
+		}
+	}
+	return "path:" + AbsPath(file)
+}
+
+// ContentHash is a Key that identifies files by the SHA-256 hash of their content,
+// so identical copies are considered equal. For directories, for files that do not
+// exist and for files that cannot be read, the absolute path is used instead.
+func ContentHash(file FileMetaInfo) string {
+	if file.Exists() && !file.IsDir() {
+		if sum, err := hashFile(file, sha256.New()); err == nil {
+			return "sha256:" + sum
+		}
+	}
+	return "path:" + AbsPath(file)
+}
+
+// hashFile hashes the content of a file, giving the sum in hexadecimal.
+func hashFile(file FileMetaInfo, h hash.Hash) (string, error) {
+	f, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// keySet holds the keys of a list of files.
+type keySet map[string]struct{}
+
+func (files Files) keySet(key Key) keySet {
+	set := make(keySet, len(files))
+	for _, f := range files {
+		set[key(f)] = struct{}{}
+	}
+	return set
+}
+
+func orDefault(key Key) Key {
+	if key == nil {
+		return CleanPath
+	}
+	return key
+}
+
+// Dedupe returns the files with duplicates removed, according to the key.
+// The first of each set of duplicates is kept and the order is otherwise preserved.
+// If key is nil, CleanPath is used.
+func (files Files) Dedupe(key Key) Files {
+	key = orDefault(key)
+	seen := make(keySet, len(files))
+	result := make(Files, 0, len(files))
+
+	for _, f := range files {
+		k := key(f)
+		if _, exists := seen[k]; !exists {
+			seen[k] = struct{}{}
+			result = append(result, f)
+		}
+	}
+
+	return result
+}
+
+// Union returns the files that are in either list, without duplicates, according
+// to the key. Those from 'files' come first, followed by those only in 'other'.
+// If key is nil, CleanPath is used.
+func (files Files) Union(other Files, key Key) Files {
+	all := make(Files, 0, len(files)+len(other))
+	all = append(all, files...)
+	all = append(all, other...)
+	return all.Dedupe(key)
+}
+
+// Intersect returns the files that are in both lists, without duplicates, according
+// to the key. The items are taken from 'files'. If key is nil, CleanPath is used.
+func (files Files) Intersect(other Files, key Key) Files {
+	key = orDefault(key)
+	set := other.keySet(key)
+	return files.Filter(func(f FileMetaInfo) bool {
+		_, exists := set[key(f)]
+		return exists
+	}).Dedupe(key)
+}
+
+// Difference returns the files that are in 'files' but not in 'other', without
+// duplicates, according to the key. If key is nil, CleanPath is used.
+func (files Files) Difference(other Files, key Key) Files {
+	key = orDefault(key)
+	set := other.keySet(key)
+	return files.Filter(func(f FileMetaInfo) bool {
+		_, exists := set[key(f)]
+		return !exists
+	}).Dedupe(key)
+}
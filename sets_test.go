@@ -0,0 +1,111 @@
+package filemod
+
+import (
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func paths(files Files) []string {
+	result := make([]string, len(files))
+	for i, f := range files {
+		result[i] = f.Path()
+	}
+	return result
+}
+
+func TestDedupe(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = &osStub{} // global; nothing exists
+	group := New("a/b", "c", "a/./b", "./c", "d")
+
+	// When...
+	result := group.Dedupe(nil)
+
+	// Then...
+	g.Expect(paths(result)).To(Equal([]string{"a/b", "c", "d"}))
+}
+
+func TestUnion(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = &osStub{} // global; nothing exists
+	g1 := New("a", "b", "a")
+	g2 := New("./b", "c")
+
+	// When...
+	result := g1.Union(g2, CleanPath)
+
+	// Then...
+	g.Expect(paths(result)).To(Equal([]string{"a", "b", "c"}))
+}
+
+func TestIntersect(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = &osStub{} // global; nothing exists
+	g1 := New("a", "b", "c", "b")
+	g2 := New("./b", "c", "d")
+
+	// When...
+	result := g1.Intersect(g2, nil)
+
+	// Then...
+	g.Expect(paths(result)).To(Equal([]string{"b", "c"}))
+}
+
+func TestDifference(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = &osStub{} // global; nothing exists
+	g1 := New("a", "b", "c", "a")
+	g2 := New("./b", "d")
+
+	// When...
+	result := g1.Difference(g2, nil)
+
+	// Then...
+	g.Expect(paths(result)).To(Equal([]string{"a", "c"}))
+}
+
+func TestAbsPath(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = &osStub{} // global; nothing exists
+	wd, _ := os.Getwd()
+	group := New("a", filepath.Join(wd, "a"), "b")
+
+	// When...
+	result := group.Dedupe(AbsPath)
+
+	// Then...
+	g.Expect(paths(result)).To(Equal([]string{"a", "b"}))
+}
+
+func TestInodeAndContentHash(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	dir, err := ioutil.TempDir("", "filemod")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir)
+
+	a := filepath.Join(dir, "a")
+	b := filepath.Join(dir, "b")
+	c := filepath.Join(dir, "c")
+	g.Expect(ioutil.WriteFile(a, []byte("hello"), 0644)).To(Succeed())
+	g.Expect(ioutil.WriteFile(c, []byte("hello"), 0644)).To(Succeed())
+	g.Expect(os.Link(a, b)).To(Succeed())
+	group := New(a, b, c)
+
+	// When...
+	byInode := group.Dedupe(Inode)
+	byHash := group.Dedupe(ContentHash)
+
+	// Then...
+	g.Expect(paths(byInode)).To(Equal([]string{a, c}))
+	g.Expect(paths(byHash)).To(Equal([]string{a}))
+}
@@ -0,0 +1,71 @@
+package filemod
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// ErrNotRefreshed is held in the errors from RunIfStale for outputs that were still
+// stale after the command had been run.
+var ErrNotRefreshed = errors.New("output was not refreshed")
+
+// Stale returns those outputs that are missing or that are not newer than all of the
+// inputs. If there are no inputs, only the missing outputs are returned.
+func Stale(outputs, inputs Files) Files {
+	var newest time.Time
+	for _, f := range inputs {
+		if f.ModTime().After(newest) {
+			newest = f.ModTime()
+		}
+	}
+
+	return outputs.Filter(func(f FileMetaInfo) bool {
+		return !f.Exists() || !f.ModTime().After(newest)
+	})
+}
+
+// RunIfStale runs a command only when any of its outputs are missing or are older than
+// any of its inputs. The command is typically a code generator or some other build step.
+//
+// It returns true if the command was run. The error is the command's error if it failed.
+// Otherwise, the outputs are checked again and any that are still stale are reported as
+// Errors holding ErrNotRefreshed. Missing inputs are reported as Errors without running
+// the command.
+func RunIfStale(outputs, inputs []string, command func() error) (ran bool, err error) {
+	in := New(inputs...)
+	if ee := missing(in); len(ee) > 0 {
+		return false, ee
+	}
+
+	if len(Stale(New(outputs...), in)) == 0 {
+		Debug("%v are up to date.\n", outputs)
+		return false, nil
+	}
+
+	if err = command(); err != nil {
+		return true, err
+	}
+
+	var ee Errors
+	for _, f := range Stale(New(outputs...), in) {
+		ee = append(ee, &os.PathError{Op: "run", Path: f.Path(), Err: ErrNotRefreshed})
+	}
+
+	if len(ee) > 0 {
+		return true, ee
+	}
+	return true, nil
+}
+
+func missing(files Files) Errors {
+	var ee Errors
+	for _, f := range files {
+		if f.Err() != nil {
+			ee = append(ee, f.Err())
+		} else if !f.Exists() {
+			ee = append(ee, &os.PathError{Op: "stat", Path: f.Path(), Err: os.ErrNotExist})
+		}
+	}
+	return ee
+}
@@ -0,0 +1,106 @@
+package filemod
+
+import (
+	"errors"
+	. "github.com/onsi/gomega"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestStale(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	now := time.Now().UTC()
+	i1 := fileInfo{name: "i1", modTime: now.Add(-2 * time.Minute)}
+	i2 := fileInfo{name: "i2", modTime: now.Add(-1 * time.Minute)}
+	o1 := fileInfo{name: "o1", modTime: now}
+	o2 := fileInfo{name: "o2", modTime: now.Add(-1 * time.Minute)}
+	x := fileInfo{err: os.ErrNotExist}
+	fs = &osStub{[]fileInfo{i1, i2, o1, o2, x}} // global
+	inputs := New("/i1", "/i2")
+	outputs := New("/o1", "/o2", "/o3")
+
+	// When...
+	stale := Stale(outputs, inputs)
+	missing := Stale(outputs, nil)
+
+	// Then...
+	g.Expect(paths(stale)).To(Equal([]string{"/o2", "/o3"}))
+	g.Expect(paths(missing)).To(Equal([]string{"/o3"}))
+}
+
+func TestRunIfStaleUpToDate(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	now := time.Now().UTC()
+	i1 := fileInfo{name: "i1", modTime: now.Add(-1 * time.Minute)}
+	o1 := fileInfo{name: "o1", modTime: now}
+	fs = &osStub{[]fileInfo{i1, o1}} // global
+
+	// When...
+	ran, err := RunIfStale([]string{"/o1"}, []string{"/i1"}, func() error {
+		panic("should not run")
+	})
+
+	// Then...
+	g.Expect(ran).To(BeFalse())
+	g.Expect(err).NotTo(HaveOccurred())
+}
+
+func TestRunIfStaleRefreshed(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	now := time.Now().UTC()
+	i1 := fileInfo{name: "i1", modTime: now.Add(-1 * time.Minute)}
+	o1 := fileInfo{name: "o1", modTime: now.Add(-2 * time.Minute)}
+	o1b := fileInfo{name: "o1", modTime: now}
+	o2 := fileInfo{name: "o2", modTime: now.Add(-2 * time.Minute)}
+	fs = &osStub{[]fileInfo{i1, o1, o2, o1b, o2}} // global
+	count := 0
+
+	// When...
+	ran, err := RunIfStale([]string{"/o1", "/o2"}, []string{"/i1"}, func() error {
+		count++
+		return nil
+	})
+
+	// Then...
+	g.Expect(ran).To(BeTrue())
+	g.Expect(count).To(Equal(1))
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(Equal("run /o2: output was not refreshed"))
+	g.Expect(errors.Is(err.(Errors)[0], ErrNotRefreshed)).To(BeTrue())
+}
+
+func TestRunIfStaleFailed(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	now := time.Now().UTC()
+	i1 := fileInfo{name: "i1", modTime: now}
+	fs = &osStub{[]fileInfo{i1}} // global; output is absent
+
+	// When...
+	ran, err := RunIfStale([]string{"/o1"}, []string{"/i1"}, func() error {
+		return errors.New("boom")
+	})
+
+	// Then...
+	g.Expect(ran).To(BeTrue())
+	g.Expect(err).To(MatchError("boom"))
+}
+
+func TestRunIfStaleMissingInput(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = &osStub{} // global; nothing exists
+
+	// When...
+	ran, err := RunIfStale([]string{"/o1"}, []string{"/i1"}, func() error {
+		panic("should not run")
+	})
+
+	// Then...
+	g.Expect(ran).To(BeFalse())
+	g.Expect(err).To(MatchError("stat /i1: file does not exist"))
+}
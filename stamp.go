@@ -0,0 +1,227 @@
+package filemod
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Fingerprint records the identity of a file at one moment. Unlike a comparison of
+// modification times, comparing fingerprints detects a file that has been replaced
+// by an older one, for example by 'git revert'.
+type Fingerprint struct {
+	Path    string    `json:"path"`
+	Exists  bool      `json:"exists"`
+	Size    int64     `json:"size,omitempty"`
+	ModTime time.Time `json:"modTime,omitempty"`
+	Dev     uint64    `json:"dev,omitempty"`
+	Ino     uint64    `json:"ino,omitempty"`
+	// SHA256 is the hash of the content, if it was requested.
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// NewFingerprint gets the fingerprint of a file, including the hash of its content
+// if required. It fails only if the hash was required and could not be computed.
+func NewFingerprint(file FileMetaInfo, withHash bool) (Fingerprint, error) {
+	fp := Fingerprint{Path: CleanPath(file), Exists: file.Exists()}
+	if !fp.Exists {
+		return fp, nil
+	}
+
+	fp.Size = file.Size()
+	fp.ModTime = file.ModTime()
+	fp.Dev, fp.Ino, _ = inodeOf(file.fi)
+
+	if withHash && !file.IsDir() {
+		sum, err := hashFile(file, sha256.New())
+		if err != nil {
+			return fp, err
+		}
+		fp.SHA256 = sum
+	}
+	return fp, nil
+}
+
+// Matches tests whether a file still has this fingerprint. If the fingerprint holds a
+// hash, the size and content decide, so a file that has merely been touched or copied
+// still matches. Otherwise, the size, modification time and inode must all be unchanged.
+func (fp Fingerprint) Matches(file FileMetaInfo) (bool, error) {
+	if fp.Exists != file.Exists() {
+		return false, nil
+	}
+	if !fp.Exists {
+		return true, nil
+	}
+	if fp.Size != file.Size() {
+		return false, nil
+	}
+
+	if fp.SHA256 != "" && !file.IsDir() {
+		sum, err := hashFile(file, sha256.New())
+		return sum == fp.SHA256, err
+	}
+
+	dev, ino, ok := inodeOf(file.fi)
+	if ok && (fp.Dev != 0 || fp.Ino != 0) && (dev != fp.Dev || ino != fp.Ino) {
+		return false, nil
+	}
+	return fp.ModTime.Equal(file.ModTime()), nil
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// Stamp records the fingerprints of the inputs of a build step, after the step has
+// succeeded. Later, the inputs are checked against the stamp to decide whether the step
+// must be run again, as with ninja's build log.
+type Stamp struct {
+	Inputs []Fingerprint `json:"inputs"`
+}
+
+// NewStamp gets the fingerprints of the inputs. Errors computing hashes are returned
+// as Errors.
+func NewStamp(inputs Files, withHash bool) (Stamp, error) {
+	st := Stamp{Inputs: make([]Fingerprint, 0, len(inputs))}
+	var ee Errors
+
+	for _, f := range inputs {
+		fp, err := NewFingerprint(f, withHash)
+		if err != nil {
+			ee = append(ee, err)
+		}
+		st.Inputs = append(st.Inputs, fp)
+	}
+
+	if len(ee) > 0 {
+		return st, ee
+	}
+	return st, nil
+}
+
+// ReadStamp reads a stamp file written by Write. Like Write, it always uses the
+// operating system's filesystem.
+func ReadStamp(name string) (Stamp, error) {
+	var st Stamp
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return st, err
+	}
+	if err = json.Unmarshal(data, &st); err != nil {
+		return st, &os.PathError{Op: "read", Path: name, Err: err}
+	}
+	return st, nil
+}
+
+// Write writes the stamp to a file as JSON, atomically, using the operating system's
+// filesystem.
+func (st Stamp) Write(name string) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	Debug("write stamp %q\n", name)
+	_, err = WriteIfChanged(name, append(data, '\n'), 0644)
+	return err
+}
+
+// Changed returns the paths of the inputs that differ from the stamp. This includes
+// inputs that have been added or removed since the stamp was made. An empty result
+// means that the inputs are unchanged. Errors computing hashes are returned as Errors.
+func (st Stamp) Changed(inputs Files) ([]string, error) {
+	recorded := make(map[string]Fingerprint, len(st.Inputs))
+	for _, fp := range st.Inputs {
+		recorded[fp.Path] = fp
+	}
+
+	var changed []string
+	var ee Errors
+	current := make(map[string]bool, len(inputs))
+
+	for _, f := range inputs {
+		p := CleanPath(f)
+		current[p] = true
+
+		fp, exists := recorded[p]
+		if !exists {
+			changed = append(changed, p)
+			continue
+		}
+
+		ok, err := fp.Matches(f)
+		if err != nil {
+			ee = append(ee, err)
+		}
+		if !ok || err != nil {
+			changed = append(changed, p)
+		}
+	}
+
+	for _, fp := range st.Inputs {
+		if !current[fp.Path] {
+			changed = append(changed, fp.Path)
+		}
+	}
+
+	if len(ee) > 0 {
+		return changed, ee
+	}
+	return changed, nil
+}
+
+// RunIfChanged runs a command only when its inputs differ from those recorded in a stamp
+// file, when there is no stamp file, or when any of its outputs are missing. After the
+// command has succeeded, the stamp file is written with the fingerprints the inputs had
+// before it was run. Hashes of the inputs' content are recorded if withHash is true.
+// The inputs and outputs are examined in the same way as by New, whereas the stamp file
+// is always on the operating system's filesystem.
+//
+// It returns true if the command was run. The error is the command's error if it failed.
+// Otherwise, any outputs that are still missing are reported as Errors holding
+// ErrNotRefreshed, and the stamp file is not written. Missing inputs are reported as
+// Errors without running the command.
+func RunIfChanged(stamp string, outputs, inputs []string, withHash bool, command func() error) (ran bool, err error) {
+	in := New(inputs...)
+	if ee := missing(in); len(ee) > 0 {
+		return false, ee
+	}
+
+	old, err := ReadStamp(stamp)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+
+	if err == nil && len(New(outputs...).AbsentOnly()) == 0 {
+		changed, err := old.Changed(in)
+		if err != nil {
+			return false, err
+		}
+		if len(changed) == 0 {
+			Debug("%v are unchanged.\n", inputs)
+			return false, nil
+		}
+	}
+
+	st, err := NewStamp(in, withHash)
+	if err != nil {
+		return false, err
+	}
+
+	if err = command(); err != nil {
+		return true, err
+	}
+
+	var ee Errors
+	for _, f := range New(outputs...).AbsentOnly() {
+		ee = append(ee, &os.PathError{Op: "run", Path: f.Path(), Err: ErrNotRefreshed})
+	}
+	if len(ee) > 0 {
+		return true, ee
+	}
+
+	if err = os.MkdirAll(filepath.Dir(stamp), 0755); err != nil {
+		return true, err
+	}
+	return true, st.Write(stamp)
+}
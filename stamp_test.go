@@ -0,0 +1,135 @@
+package filemod
+
+import (
+	"errors"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRunIfChanged(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	dir := tempTree(g, "a", "b")
+	defer os.RemoveAll(dir)
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	out := filepath.Join(dir, "out")
+	stamp := filepath.Join(dir, ".stamps", "step")
+	runs := 0
+	step := func() error { runs++; return ioutil.WriteFile(out, nil, 0644) }
+
+	// When...
+	ran1, err1 := RunIfChanged(stamp, []string{out}, []string{a, b}, false, step)
+	ran2, err2 := RunIfChanged(stamp, []string{out}, []string{a, b}, false, step)
+
+	// an older file replaces a, as with 'git revert'
+	older := time.Now().Add(-time.Hour)
+	g.Expect(ioutil.WriteFile(a, []byte("x"), 0644)).To(Succeed())
+	g.Expect(os.Chtimes(a, older, older)).To(Succeed())
+	ran3, err3 := RunIfChanged(stamp, []string{out}, []string{a, b}, false, step)
+	ran4, err4 := RunIfChanged(stamp, []string{out}, []string{a, b}, false, step)
+
+	// the output is deleted
+	g.Expect(os.Remove(out)).To(Succeed())
+	ran5, err5 := RunIfChanged(stamp, []string{out}, []string{a, b}, false, step)
+	ran6, err6 := RunIfChanged(stamp, []string{out}, []string{a, b}, false, step)
+
+	// Then...
+	g.Expect(err1).NotTo(HaveOccurred())
+	g.Expect(err2).NotTo(HaveOccurred())
+	g.Expect(err3).NotTo(HaveOccurred())
+	g.Expect(err4).NotTo(HaveOccurred())
+	g.Expect(err5).NotTo(HaveOccurred())
+	g.Expect(err6).NotTo(HaveOccurred())
+	g.Expect([]bool{ran1, ran2, ran3, ran4, ran5, ran6}).To(Equal([]bool{true, false, true, false, true, false}))
+	g.Expect(runs).To(Equal(3))
+}
+
+func TestRunIfChangedFailures(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	dir := tempTree(g, "a")
+	defer os.RemoveAll(dir)
+	a := filepath.Join(dir, "a")
+	out := filepath.Join(dir, "out")
+	stamp := filepath.Join(dir, "stamp")
+	boom := errors.New("boom")
+
+	// When...
+	ran1, err1 := RunIfChanged(stamp, nil, []string{a}, false, func() error { return boom })
+	ran2, err2 := RunIfChanged(stamp, nil, []string{a, filepath.Join(dir, "nope")}, false, func() error { return nil })
+	ran3, err3 := RunIfChanged(stamp, []string{out}, []string{a}, false, func() error { return nil })
+
+	// Then...
+	g.Expect(ran1).To(BeTrue())
+	g.Expect(err1).To(Equal(boom))
+	g.Expect(Stat(stamp).Exists()).To(BeFalse())
+	g.Expect(ran2).To(BeFalse())
+	g.Expect(errors.Is(err2.(Errors)[0], os.ErrNotExist)).To(BeTrue())
+	g.Expect(ran3).To(BeTrue())
+	g.Expect(errors.Is(err3.(Errors)[0], ErrNotRefreshed)).To(BeTrue())
+	g.Expect(Stat(stamp).Exists()).To(BeFalse())
+}
+
+func TestStampChanged(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	dir := tempTree(g, "a", "b", "c")
+	defer os.RemoveAll(dir)
+	a, b, c := filepath.Join(dir, "a"), filepath.Join(dir, "b"), filepath.Join(dir, "c")
+	name := filepath.Join(dir, "stamp")
+
+	hashed, err := NewStamp(New(a, b), true)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(hashed.Write(name)).To(Succeed())
+	plain, err := NewStamp(New(a, b), false)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// When...
+	later := time.Now().Add(time.Hour)
+	g.Expect(os.Chtimes(a, later, later)).To(Succeed())
+	g.Expect(ioutil.WriteFile(b, []byte("B"), 0644)).To(Succeed()) // same size
+	st, err := ReadStamp(name)
+	g.Expect(err).NotTo(HaveOccurred())
+	changedHashed, err1 := st.Changed(New(a, b))
+	changedPlain, err2 := plain.Changed(New(a, c))
+
+	// Then...
+	g.Expect(err1).NotTo(HaveOccurred())
+	g.Expect(err2).NotTo(HaveOccurred())
+	g.Expect(st.Inputs[0].SHA256).To(HaveLen(64))
+	g.Expect(changedHashed).To(Equal([]string{b}))
+	g.Expect(changedPlain).To(Equal([]string{a, c, b}))
+}
+
+func TestRunIfChangedWithMemFS(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	mem := NewMemFS(time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC))
+	g.Expect(mem.WriteFile("/src/a", []byte("a"), 0644)).To(Succeed())
+	g.Expect(mem.WriteFile("/out/x", []byte("x"), 0644)).To(Succeed())
+	fs = mem // global
+	dir, err := ioutil.TempDir("", "filemod")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir)
+	stamp := filepath.Join(dir, "step")
+	runs := 0
+	step := func() error { runs++; return nil }
+
+	// When...
+	ran1, err1 := RunIfChanged(stamp, []string{"/out/x"}, []string{"/src/a"}, false, step)
+	ran2, err2 := RunIfChanged(stamp, []string{"/out/x"}, []string{"/src/a"}, false, step)
+
+	// Then...
+	g.Expect(err1).NotTo(HaveOccurred())
+	g.Expect(err2).NotTo(HaveOccurred())
+	g.Expect([]bool{ran1, ran2}).To(Equal([]bool{true, false}))
+	g.Expect(runs).To(Equal(1))
+	g.Expect(stamp).To(BeAnExistingFile())
+}
@@ -0,0 +1,134 @@
+package filemod
+
+import (
+	"iter"
+)
+
+// Stream is a sequence of file information, produced one item at a time. It allows huge
+// numbers of files to be processed in constant memory; a Files slice is only built if
+// Collect is used. A Stream is an iter.Seq[FileMetaInfo].
+type Stream iter.Seq[FileMetaInfo]
+
+// Stream gets a Stream of the files in the list.
+func (files Files) Stream() Stream {
+	return func(yield func(FileMetaInfo) bool) {
+		for _, f := range files {
+			if !yield(f) {
+				return
+			}
+		}
+	}
+}
+
+// StreamPaths gets a Stream of file information for a sequence of paths. Each path
+// is only tested when its file information is needed.
+func StreamPaths(paths iter.Seq[string]) Stream {
+	return func(yield func(FileMetaInfo) bool) {
+		for p := range paths {
+			if !yield(Stat(p)) {
+				return
+			}
+		}
+	}
+}
+
+// StreamWalk is like Walk, except that it produces the files one at a time. Memory is
+// only needed for the listing of each directory on the current path.
+// Errors from reading directories are passed to onError, which may be nil.
+func StreamWalk(root string, onError func(error)) Stream {
+	return Walker{}.Stream(root, onError)
+}
+
+// StreamWalkWith is like StreamWalk, using a particular Statter, which must also be
+// a DirReader.
+func StreamWalkWith(s Statter, root string, onError func(error)) Stream {
+	return Walker{Statter: s}.Stream(root, onError)
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// Filter is a streaming stage that passes on only those items for which a predicate p
+// returns true.
+func (st Stream) Filter(p func(info FileMetaInfo) bool) Stream {
+	return func(yield func(FileMetaInfo) bool) {
+		for f := range st {
+			if p(f) && !yield(f) {
+				return
+			}
+		}
+	}
+}
+
+// Partition consumes the stream, passing files and directories that exist, and items
+// that don't, to the respective functions, any of which may be nil.
+func (st Stream) Partition(file, dir, absent func(FileMetaInfo)) {
+	for f := range st {
+		var fn func(FileMetaInfo)
+		switch {
+		case f.Exists() && f.IsDir():
+			fn = dir
+		case f.Exists():
+			fn = file
+		default:
+			fn = absent
+		}
+		if fn != nil {
+			fn(f)
+		}
+	}
+}
+
+// Collect consumes the stream, building a list of the files.
+func (st Stream) Collect() Files {
+	var result Files
+	for f := range st {
+		result = append(result, f)
+	}
+	return result
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// Summary holds aggregate information about a group of files.
+type Summary struct {
+	// Files, Dirs and Absent count the files, directories and absent items.
+	Files, Dirs, Absent int
+	// Errors counts the items that had errors.
+	Errors int
+	// TotalSize is the sum of the sizes of the files (but not the directories).
+	TotalSize int64
+	// Oldest and Newest are the existing items with the oldest and newest modification times.
+	Oldest, Newest *FileMetaInfo
+}
+
+// Summarise consumes the stream, aggregating information about the files.
+func (st Stream) Summarise() Summary {
+	var s Summary
+	for f := range st {
+		if f.err != nil {
+			s.Errors++
+		}
+
+		if !f.Exists() {
+			s.Absent++
+			continue
+		}
+
+		if f.IsDir() {
+			s.Dirs++
+		} else {
+			s.Files++
+			s.TotalSize += f.Size()
+		}
+
+		if s.Oldest == nil || f.ModTime().Before(s.Oldest.ModTime()) {
+			oldest := f
+			s.Oldest = &oldest
+		}
+		if s.Newest == nil || f.ModTime().After(s.Newest.ModTime()) {
+			newest := f
+			s.Newest = &newest
+		}
+	}
+	return s
+}
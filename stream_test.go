@@ -0,0 +1,105 @@
+package filemod
+
+import (
+	"errors"
+	. "github.com/onsi/gomega"
+	"slices"
+	"testing"
+	"time"
+)
+
+func streamFixture(g *WithT) (*MemFS, time.Time) {
+	t0 := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	mem := NewMemFS(t0)
+	g.Expect(mem.WriteFile("/src/a.go", []byte("package a"), 0644)).To(Succeed())
+	mem.Advance(time.Minute)
+	g.Expect(mem.WriteFile("/src/b.go", []byte("package bb"), 0644)).To(Succeed())
+	g.Expect(mem.WriteFile("/src/sub/c.go", []byte("package c"), 0644)).To(Succeed())
+	g.Expect(mem.MkdirAll("/out", 0755)).To(Succeed())
+	return mem, t0
+}
+
+func TestStreamWalk(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	mem, _ := streamFixture(g)
+	boom := errors.New("boom")
+	mem.InjectError("/src/sub", boom)
+	var ee Errors
+
+	// When...
+	files := StreamWalkWith(mem, "/", func(err error) { ee = append(ee, err) }).Collect()
+	walked, err := WalkWith(mem, "/")
+
+	// Then...
+	g.Expect(paths(files)).To(Equal([]string{"/", "/out", "/src", "/src/a.go", "/src/b.go", "/src/sub"}))
+	g.Expect(paths(walked)).To(Equal(paths(files)))
+	g.Expect(ee).To(HaveLen(1))
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(errors.Is(ee[0], boom)).To(BeTrue())
+}
+
+func TestStreamStopsEarly(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	mem, _ := streamFixture(g)
+	var visited []string
+
+	// When...
+	for f := range StreamWalkWith(mem, "/src", nil) {
+		visited = append(visited, f.Path())
+		if f.Name() == "a.go" {
+			break
+		}
+	}
+
+	// Then...
+	g.Expect(visited).To(Equal([]string{"/src", "/src/a.go"}))
+}
+
+func TestStreamFilterAndSummarise(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	mem, t0 := streamFixture(g)
+	st := StreamWalkWith(mem, "/src", nil)
+	notDir := func(f FileMetaInfo) bool { return !f.IsDir() }
+
+	// When...
+	files := st.Filter(notDir).Collect()
+	summary := st.Summarise()
+
+	// Then...
+	g.Expect(paths(files)).To(Equal([]string{"/src/a.go", "/src/b.go", "/src/sub/c.go"}))
+	g.Expect(summary.Files).To(Equal(3))
+	g.Expect(summary.Dirs).To(Equal(2))
+	g.Expect(summary.Absent).To(Equal(0))
+	g.Expect(summary.TotalSize).To(BeEquivalentTo(28))
+	g.Expect(summary.Oldest.Path()).To(Equal("/src/a.go"))
+	g.Expect(summary.Oldest.ModTime()).To(Equal(t0))
+	g.Expect(summary.Newest.ModTime()).To(Equal(t0.Add(time.Minute)))
+}
+
+func TestStreamPartition(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	mem, _ := streamFixture(g)
+	fs = mem // global
+	defer func() { fs = OS }()
+	var files, dirs, absent []string
+
+	// When...
+	StreamPaths(slices.Values([]string{"/src", "/src/a.go", "/nope", "/out"})).Partition(
+		func(f FileMetaInfo) { files = append(files, f.Path()) },
+		func(f FileMetaInfo) { dirs = append(dirs, f.Path()) },
+		func(f FileMetaInfo) { absent = append(absent, f.Path()) },
+	)
+	summary := New("/src/b.go", "/nope").Stream().Summarise()
+
+	// Then...
+	g.Expect(files).To(Equal([]string{"/src/a.go"}))
+	g.Expect(dirs).To(Equal([]string{"/src", "/out"}))
+	g.Expect(absent).To(Equal([]string{"/nope"}))
+	g.Expect(summary.Files).To(Equal(1))
+	g.Expect(summary.Absent).To(Equal(1))
+	g.Expect(summary.Errors).To(Equal(0))
+}
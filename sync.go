@@ -0,0 +1,303 @@
+package filemod
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ActionKind enumerates the steps in a SyncPlan.
+type ActionKind int
+
+const (
+	// Mkdir creates a directory.
+	Mkdir ActionKind = iota + 1
+	// Copy copies a file that is not in the destination.
+	Copy
+	// Update replaces a file in the destination, or changes the mode of a directory.
+	Update
+	// Delete removes a file or directory tree from the destination.
+	Delete
+)
+
+var actionNames = []string{Mkdir: "mkdir", Copy: "copy", Update: "update", Delete: "delete"}
+
+func (k ActionKind) String() string {
+	if k >= Mkdir && k <= Delete {
+		return actionNames[k]
+	}
+	return fmt.Sprintf("ActionKind(%d)", int(k))
+}
+
+// MarshalText encodes the kind as its name, so that plans are readable as JSON.
+func (k ActionKind) MarshalText() ([]byte, error) {
+	if k < Mkdir || k > Delete {
+		return nil, fmt.Errorf("invalid %v", k)
+	}
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText decodes a kind from its name.
+func (k *ActionKind) UnmarshalText(text []byte) error {
+	for i := Mkdir; i <= Delete; i++ {
+		if actionNames[i] == string(text) {
+			*k = i
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown action %q", text)
+}
+
+// Action is one step in a SyncPlan. For actions other than Delete, the size, mode and
+// modification time are those of the source, which the destination will be given.
+type Action struct {
+	Kind ActionKind `json:"kind"`
+	// Path is slash-separated and relative to the source and destination roots.
+	Path    string      `json:"path"`
+	Size    int64       `json:"size,omitempty"`
+	Mode    os.FileMode `json:"mode,omitempty"`
+	ModTime time.Time   `json:"modTime,omitempty"`
+}
+
+// SyncOptions controls how a SyncPlan is made.
+type SyncOptions struct {
+	// Delete removes files from the destination that are not in the source, as
+	// with 'rsync --delete'.
+	Delete bool
+	// Hash compares the content of files whose sizes and modes match, so that files that
+	// differ only in their modification times are not copied, as with 'rsync --checksum'.
+	// The modification times of such files are left alone.
+	Hash bool
+}
+
+// SyncPlan is the list of actions that would make a destination tree mirror a source
+// tree. A plan can be inspected, or serialised as JSON, before it is executed.
+type SyncPlan struct {
+	Source  string   `json:"source"`
+	Dest    string   `json:"dest"`
+	Actions []Action `json:"actions"`
+}
+
+// PlanSync compares a source tree with a destination tree, which need not exist, and
+// plans how to make the destination mirror the source. Files are compared by type,
+// size, mode and modification time (in whole seconds), as with Diff, and optionally by
+// content. Only regular files and directories are copied; other items are skipped.
+// The trees must not overlap.
+//
+// The deletions come first, deepest first, followed by the other actions in path order.
+// Each destination directory whose contents will change is also updated, so that it
+// gets its source modification time back. Errors from walking the trees or from
+// hashing are returned as Errors; the plan excludes the files affected.
+func PlanSync(source, dest string, opts SyncOptions) (SyncPlan, error) {
+	plan := SyncPlan{Source: source, Dest: dest}
+	if overlap(source, dest) || overlap(dest, source) {
+		return plan, &os.PathError{Op: "sync", Path: dest, Err: errOverlap}
+	}
+
+	var ee Errors
+
+	src, err := Walk(source)
+	if err != nil {
+		ee = append(ee, err.(Errors)...)
+	}
+	if !src[0].Exists() {
+		return plan, &os.PathError{Op: "sync", Path: source, Err: os.ErrNotExist}
+	}
+
+	dst, err := Walk(dest)
+	if err != nil {
+		ee = append(ee, err.(Errors)...)
+	}
+
+	relative := RelativeTo(source, dest)
+	key := func(f FileMetaInfo) string {
+		return filepath.ToSlash(relative(f))
+	}
+
+	var deletes, others []Action
+	for _, c := range Diff(dst, src, key) {
+		from, to := c.After, c.Before
+		switch {
+		case c.Kind == Removed:
+			if opts.Delete {
+				deletes = append(deletes, Action{Kind: Delete, Path: c.Key})
+			}
+
+		case !from.IsDir() && !from.Mode().IsRegular():
+			Debug("skip %q\n", from.path)
+
+		case c.Kind == Added:
+			others = append(others, newAction(Copy, c.Key, from))
+
+		case from.IsDir() != to.IsDir():
+			deletes = append(deletes, Action{Kind: Delete, Path: c.Key})
+			others = append(others, newAction(Copy, c.Key, from))
+
+		case opts.Hash && !from.IsDir() && from.Size() == to.Size() && from.Mode() == to.Mode():
+			same, err := sameHash(from, to)
+			if err != nil {
+				ee = append(ee, err)
+			} else if !same {
+				others = append(others, newAction(Update, c.Key, from))
+			}
+
+		default:
+			others = append(others, newAction(Update, c.Key, from))
+		}
+	}
+
+	// directories' times change as their contents change, so they are restored afterwards
+	planned := make(keySet, len(others))
+	for _, a := range others {
+		planned[a.Path] = struct{}{}
+	}
+	touched := make(keySet)
+	for _, a := range append(deletes, others...) {
+		if a.Kind != Update || !a.Mode.IsDir() {
+			touched[path.Dir(a.Path)] = struct{}{}
+		}
+	}
+	dstByKey := make(map[string]FileMetaInfo, len(dst))
+	for _, f := range dst {
+		dstByKey[key(f)] = f
+	}
+	for _, f := range src {
+		k := key(f)
+		to, exists := dstByKey[k]
+		_, done := planned[k]
+		_, changed := touched[k]
+		if !done && f.IsDir() && exists && to.IsDir() &&
+			(changed || !f.ModTime().Truncate(time.Second).Equal(to.ModTime().Truncate(time.Second))) {
+			others = append(others, newAction(Update, k, f))
+		}
+	}
+
+	sort.Slice(deletes, func(i, j int) bool { return deletes[i].Path > deletes[j].Path })
+	sort.Slice(others, func(i, j int) bool { return others[i].Path < others[j].Path })
+	plan.Actions = append(deletes, others...)
+
+	if len(ee) > 0 {
+		return plan, ee
+	}
+	return plan, nil
+}
+
+var errOverlap = errors.New("source and destination overlap")
+
+// overlap tests whether one tree contains another.
+func overlap(outer, inner string) bool {
+	rel, err := filepath.Rel(outer, inner)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func newAction(kind ActionKind, path string, from FileMetaInfo) Action {
+	if from.IsDir() && kind == Copy {
+		kind = Mkdir
+	}
+	return Action{Kind: kind, Path: path, Size: from.Size(), Mode: from.Mode(), ModTime: from.ModTime()}
+}
+
+func sameHash(a, b FileMetaInfo) (bool, error) {
+	ha, err := hashFile(a, sha256.New())
+	if err != nil {
+		return false, err
+	}
+	hb, err := hashFile(b, sha256.New())
+	return ha == hb, err
+}
+
+// String lists the actions, one per line.
+func (plan SyncPlan) String() string {
+	buf := &strings.Builder{}
+	for _, a := range plan.Actions {
+		fmt.Fprintf(buf, "%-6s %s\n", a.Kind, a.Path)
+	}
+	return buf.String()
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// Execute carries out the plan. Files are copied atomically and are given the modes and
+// modification times of their sources; so are directories, once their contents have
+// been copied. Execution continues after failures, which are returned as Errors.
+//
+// Unlike PlanSync, which examines the trees in the same way as Walk, Execute always
+// works on the operating system's filesystem.
+func (plan SyncPlan) Execute() error {
+	var ee Errors
+	var dirs []Action
+
+	for _, a := range plan.Actions {
+		src := filepath.Join(plan.Source, filepath.FromSlash(a.Path))
+		dst := filepath.Join(plan.Dest, filepath.FromSlash(a.Path))
+		Debug("%s %q\n", a.Kind, dst)
+
+		var err error
+		switch {
+		case a.Kind == Delete:
+			err = os.RemoveAll(dst)
+		case a.Mode.IsDir():
+			err = os.MkdirAll(dst, a.Mode.Perm()|0700) // writable until its contents are copied
+			dirs = append(dirs, a)
+		case a.Kind == Copy || a.Kind == Update:
+			err = copyFile(src, dst, a)
+		default:
+			err = fmt.Errorf("invalid action %v for %s", a.Kind, a.Path)
+		}
+
+		if err != nil {
+			ee = append(ee, err)
+		}
+	}
+
+	// directories are finished last, deepest first, because copying into them changes their times
+	for i := len(dirs) - 1; i >= 0; i-- {
+		dst := filepath.Join(plan.Dest, filepath.FromSlash(dirs[i].Path))
+		if err := os.Chmod(dst, dirs[i].Mode.Perm()); err != nil {
+			ee = append(ee, err)
+		} else if err := os.Chtimes(dst, dirs[i].ModTime, dirs[i].ModTime); err != nil {
+			ee = append(ee, err)
+		}
+	}
+
+	if len(ee) > 0 {
+		return ee
+	}
+	return nil
+}
+
+func copyFile(src, dst string, a Action) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	w, err := NewAtomicWriter(dst, a.Mode.Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(w, in); err != nil {
+		w.Abort()
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+
+	if !w.Changed() {
+		// the content was already correct
+		if err = os.Chmod(dst, a.Mode.Perm()); err != nil {
+			return err
+		}
+	}
+	return os.Chtimes(dst, a.ModTime, a.ModTime)
+}
@@ -0,0 +1,123 @@
+package filemod
+
+import (
+	"encoding/json"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var syncTime = time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func setTimes(g *WithT, dir string, names ...string) {
+	for _, name := range names {
+		g.Expect(os.Chtimes(filepath.Join(dir, filepath.FromSlash(name)), syncTime, syncTime)).To(Succeed())
+	}
+}
+
+func TestPlanAndExecuteSync(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	src := tempTree(g, "a", "d/b", "d/e/c", "same")
+	defer os.RemoveAll(src)
+	g.Expect(os.Chmod(filepath.Join(src, "d", "b"), 0755)).To(Succeed())
+	setTimes(g, src, "a", "d/b", "d/e/c", "same", "d/e", "d", ".")
+
+	dst := tempTree(g, "same", "d", "old", "x/y/")
+	defer os.RemoveAll(dst)
+	g.Expect(ioutil.WriteFile(filepath.Join(dst, "a"), []byte("changed"), 0644)).To(Succeed())
+	setTimes(g, dst, "same")
+
+	// When...
+	plan, err := PlanSync(src, dst, SyncOptions{Delete: true})
+	g.Expect(err).NotTo(HaveOccurred())
+	b, err := json.Marshal(plan)
+	g.Expect(err).NotTo(HaveOccurred())
+	var decoded SyncPlan
+	g.Expect(json.Unmarshal(b, &decoded)).To(Succeed())
+	err = decoded.Execute()
+
+	// Then...
+	g.Expect(plan.String()).To(Equal(
+		"delete x/y\n" +
+			"delete x\n" +
+			"delete old\n" +
+			"delete d\n" +
+			"update .\n" +
+			"update a\n" +
+			"mkdir  d\n" +
+			"copy   d/b\n" +
+			"mkdir  d/e\n" +
+			"copy   d/e/c\n"))
+	g.Expect(decoded.Actions).To(HaveLen(len(plan.Actions)))
+	g.Expect(decoded.Actions[7].Kind).To(Equal(Copy))
+
+	g.Expect(err).NotTo(HaveOccurred())
+	before, _ := Walk(src)
+	after, _ := Walk(dst)
+	g.Expect(Diff(before, after, RelativeTo(src, dst))).To(BeEmpty())
+	g.Expect(after.Filter(func(f FileMetaInfo) bool { return !f.ModTime().Equal(syncTime) })).To(BeEmpty())
+	g.Expect(Stat(filepath.Join(dst, "d", "b")).Mode().Perm()).To(Equal(os.FileMode(0755)))
+
+	again, err := PlanSync(src, dst, SyncOptions{Delete: true})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(again.Actions).To(BeEmpty())
+}
+
+func TestExecuteRestoresParentTimes(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	src := tempTree(g, "d/")
+	defer os.RemoveAll(src)
+	g.Expect(ioutil.WriteFile(filepath.Join(src, "d", "b"), []byte("new"), 0644)).To(Succeed())
+	setTimes(g, src, "d/b", "d", ".")
+
+	dst := tempTree(g, "d/extra")
+	defer os.RemoveAll(dst)
+	g.Expect(ioutil.WriteFile(filepath.Join(dst, "d", "b"), []byte("old!"), 0644)).To(Succeed())
+	setTimes(g, dst, "d/b", "d/extra", "d", ".")
+
+	// When...
+	plan, err := PlanSync(src, dst, SyncOptions{Delete: true})
+	g.Expect(err).NotTo(HaveOccurred())
+	err = plan.Execute()
+
+	// Then...
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(plan.String()).To(Equal("delete d/extra\nupdate d\nupdate d/b\n"))
+	after, _ := Walk(dst)
+	g.Expect(after.Filter(func(f FileMetaInfo) bool { return !f.ModTime().Equal(syncTime) })).To(BeEmpty())
+
+	again, err := PlanSync(src, dst, SyncOptions{Delete: true})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(again.Actions).To(BeEmpty())
+}
+
+func TestPlanSyncWithHash(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	src := tempTree(g, "same", "extra")
+	defer os.RemoveAll(src)
+	dst := tempTree(g, "same", "other")
+	defer os.RemoveAll(dst)
+	setTimes(g, src, "same", ".")
+	setTimes(g, dst, ".")
+
+	// When...
+	plain, err1 := PlanSync(src, dst, SyncOptions{})
+	hashed, err2 := PlanSync(src, dst, SyncOptions{Hash: true})
+	_, err3 := PlanSync(src, filepath.Join(src, "sub"), SyncOptions{})
+
+	// Then...
+	g.Expect(err1).NotTo(HaveOccurred())
+	g.Expect(err2).NotTo(HaveOccurred())
+	g.Expect(plain.String()).To(Equal("update .\ncopy   extra\nupdate same\n"))
+	g.Expect(hashed.String()).To(Equal("update .\ncopy   extra\n"))
+	g.Expect(err3).To(MatchError(errOverlap))
+}
@@ -0,0 +1,104 @@
+package filemod
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+func readDir(s Statter, path string) ([]os.FileInfo, error) {
+	dr, ok := s.(DirReader)
+	if !ok {
+		return nil, &os.PathError{Op: "readdir", Path: path, Err: errNotSupported}
+	}
+	return dr.ReadDir(path)
+}
+
+// Walk builds file information for a root path and, if it is a directory, everything
+// beneath it. The files are in lexical order, with each directory preceding its contents.
+// Symbolic links are not followed.
+//
+// Directories that cannot be read are included but their contents are not; the
+// errors are returned as Errors. Errors from Lstat are held in the files returned.
+func Walk(root string) (Files, error) {
+	return Walker{}.Walk(root)
+}
+
+// WalkWith walks a root path in the same way as Walk, using a particular Statter,
+// which must also be a DirReader.
+func WalkWith(s Statter, root string) (Files, error) {
+	return Walker{Statter: s}.Walk(root)
+}
+
+//-------------------------------------------------------------------------------------------------
+
+// Walker walks directory trees with optional settings. The zero value walks the default
+// filesystem, in the same way as Walk.
+type Walker struct {
+	// Statter is the filesystem to walk, which must also be a DirReader. If it is nil,
+	// the default filesystem is used.
+	Statter Statter
+	// Skip, if not nil, excludes the items for which it returns true. The contents of
+	// skipped directories are not read. Ignorer.Ignored is an example.
+	Skip func(FileMetaInfo) bool
+}
+
+// Walk walks a root path in the same way as the Walk function, subject to the walker's settings.
+func (w Walker) Walk(root string) (Files, error) {
+	var ee Errors
+	result := w.Stream(root, func(err error) {
+		ee = append(ee, err)
+	}).Collect()
+
+	if len(ee) > 0 {
+		return result, ee
+	}
+	return result, nil
+}
+
+// Stream walks a root path in the same way as StreamWalk, subject to the walker's settings.
+// Errors from reading directories are passed to onError, which may be nil.
+func (w Walker) Stream(root string, onError func(error)) Stream {
+	return func(yield func(FileMetaInfo) bool) {
+		s := w.Statter
+		if s == nil {
+			s = fs
+		}
+
+		var walk func(path string, file FileMetaInfo) bool
+		walk = func(path string, file FileMetaInfo) bool {
+			if w.Skip != nil && w.Skip(file) {
+				return true
+			}
+			if !yield(file) {
+				return false
+			}
+			if !file.Exists() || !file.IsDir() {
+				return true
+			}
+
+			Debug("readdir %q\n", path)
+			list, err := readDir(s, path)
+			if err != nil {
+				if onError != nil {
+					onError(err)
+				}
+				return true
+			}
+
+			sort.Slice(list, func(i, j int) bool {
+				return list[i].Name() < list[j].Name()
+			})
+
+			for _, fi := range list {
+				child := filepath.Join(path, fi.Name())
+				if !walk(child, FileMetaInfo{path: child, fi: fi, fs: s}) {
+					return false
+				}
+			}
+			return true
+		}
+
+		walk(root, LstatWith(s, root))
+	}
+}
@@ -0,0 +1,81 @@
+package filemod
+
+import (
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// tempTree creates a directory tree holding the named files, each containing its
+// own name. Names ending with a slash are created as directories.
+func tempTree(g *WithT, names ...string) string {
+	dir, err := ioutil.TempDir("", "filemod")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	for _, name := range names {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if name[len(name)-1] == '/' {
+			g.Expect(os.MkdirAll(path, 0755)).To(Succeed())
+		} else {
+			g.Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+			g.Expect(ioutil.WriteFile(path, []byte(name), 0644)).To(Succeed())
+		}
+	}
+
+	return dir
+}
+
+func TestWalk(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+	dir := tempTree(g, "b/y", "b/x", "a", "c/")
+	defer os.RemoveAll(dir)
+
+	// When...
+	files, err := Walk(dir)
+
+	// Then...
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(paths(files)).To(Equal([]string{
+		dir,
+		filepath.Join(dir, "a"),
+		filepath.Join(dir, "b"),
+		filepath.Join(dir, "b", "x"),
+		filepath.Join(dir, "b", "y"),
+		filepath.Join(dir, "c"),
+	}))
+	g.Expect(files[0].IsDir()).To(BeTrue())
+	g.Expect(files[1].Size()).To(BeEquivalentTo(1))
+	g.Expect(files[3].Name()).To(Equal("x"))
+}
+
+func TestWalkMissing(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = osFacade{} // the real deal
+
+	// When...
+	files, err := Walk("/etc/this-does-not-exist")
+
+	// Then...
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(files).To(HaveLen(1))
+	g.Expect(files[0].Exists()).To(BeFalse())
+}
+
+func TestWalkUnsupported(t *testing.T) {
+	g := NewGomegaWithT(t)
+	// Given...
+	fs = &osStub{[]fileInfo{{name: "d", isDir: true}}} // global
+
+	// When...
+	files, err := Walk("/d")
+
+	// Then...
+	g.Expect(files).To(HaveLen(1))
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(Equal("readdir /d: operation not supported"))
+}